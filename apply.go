@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
@@ -21,7 +22,7 @@ type mapData struct {
 
 func ApplyThemeCmd(theme Theme, themeList []list.Item) tea.Cmd {
 	return func() tea.Msg {
-		err := applyTheme(theme)
+		result, err := applyTheme(theme)
 
 		if err != nil {
 			for i, item := range themeList {
@@ -31,57 +32,131 @@ func ApplyThemeCmd(theme Theme, themeList []list.Item) tea.Cmd {
 					break
 				}
 			}
-			return updateThemeListMsg(themeList)
+			return applyResultMsg{result: result, themeListItems: themeList}
 		}
 
-		return UpdateActiveStyles()
+		return applyResultMsg{result: result}
 	}
 }
 
-func applyTheme(theme Theme) error {
+// AppStatus is what happened to an app when a theme was applied.
+type AppStatus string
+
+const (
+	appWritten   AppStatus = "written"
+	appUnchanged AppStatus = "unchanged"
+	appFailed    AppStatus = "failed"
+)
+
+// ApplyResult is the status of each active app a theme was applied to.
+type ApplyResult map[string]AppStatus
+
+// String lists the apps and their status, e.g. "kitty written, waybar unchanged".
+func (r ApplyResult) String() string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := []string{}
+	for _, name := range names {
+		parts = append(parts, name+" "+string(r[name]))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (r ApplyResult) apps() map[string]string {
+	apps := make(map[string]string, len(r))
+	for name, status := range r {
+		apps[name] = string(status)
+	}
+	return apps
+}
+
+// applyLogMessage adds the status of the apps to a log message.
+func applyLogMessage(message string, result ApplyResult) string {
+	if len(result) == 0 {
+		return message
+	}
+
+	return message + ": " + result.String()
+}
+
+// applyMu serialises applies from the TUI, watcher, scheduler and daemon so
+// they don't write app files and apps.yaml at the same time.
+var applyMu sync.Mutex
+
+func applyTheme(theme Theme) (ApplyResult, error) {
+	return applyThemeToApps(theme, nil)
+}
+
+// applyThemeToApps renders the theme into the named apps, or every app when
+// names is nil. Only applying to every app makes the theme the active theme,
+// recolours terminals and runs the theme hook. Apps whose file already has
+// the rendered content are not written and their hook isn't run.
+func applyThemeToApps(theme Theme, names []string) (ApplyResult, error) {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
+	all := names == nil
+	result := ApplyResult{}
+
+	themeData, err := ReadSchemeFile(theme.Path)
+	if err != nil {
+		return result, err
+	}
+
+	scheme := builder.Scheme{}
+
+	err = yaml.Unmarshal([]byte(themeData), &scheme)
+	if err != nil {
+		return result, err
+	}
+
 	rawData, err := os.ReadFile(config.Paths.Apps)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			dir := filepath.Dir(config.Paths.ActiveTheme)
-			err := os.MkdirAll(dir, 0777)
-			if err != nil {
-				return err
+			if !all {
+				return result, nil
 			}
 
-			err = os.WriteFile(config.Paths.ActiveTheme, []byte(theme.Path), 0666)
+			recolorTerminalsIfEnabled(scheme)
+			updateShellInit(scheme)
+
+			err = WriteState(State{ActiveTheme: theme.Key()})
 			if err != nil {
-				return err
+				return result, err
 			}
 
-			return nil
+			return result, RecordRecentTheme(theme.Key())
 		}
-		return err
+		return result, err
 	}
 
 	appsMap := make(map[string]App)
 
 	err = yaml.Unmarshal([]byte(rawData), &appsMap)
 	if err != nil {
-		return err
-	}
-
-	themeData, err := os.ReadFile(theme.Path)
-	if err != nil {
-		return err
+		return result, err
 	}
 
-	scheme := builder.Scheme{}
-
-	err = yaml.Unmarshal([]byte(themeData), &scheme)
-	if err != nil {
-		return err
+	jobs := []string{}
+	for key := range appsMap {
+		if all || slices.Contains(names, key) {
+			jobs = append(jobs, key)
+		}
 	}
 
 	wg := sync.WaitGroup{}
 
-	data := mapData{Data: appsMap}
+	// Apps the workers change are collected here and copied back once they
+	// are done, so appsMap is never written while it's read
+	data := mapData{Data: make(map[string]App)}
 
-	for key, app := range data.Data {
+	for _, key := range jobs {
+		app := appsMap[key]
 
 		wg.Add(1)
 
@@ -89,6 +164,12 @@ func applyTheme(theme Theme) error {
 
 			defer wg.Done()
 
+			setStatus := func(status AppStatus) {
+				data.mu.Lock()
+				defer data.mu.Unlock()
+				result[key] = status
+			}
+
 			if !app.Active || app.Path == "" || app.Template == "" {
 				app.Active = false
 				data.mu.Lock()
@@ -99,6 +180,7 @@ func applyTheme(theme Theme) error {
 
 			templates, err := os.ReadDir(filepath.Join(config.Paths.Templates, app.Name))
 			if err != nil {
+				setStatus(appFailed)
 				return
 			}
 
@@ -122,47 +204,65 @@ func applyTheme(theme Theme) error {
 				data.mu.Lock()
 				defer data.mu.Unlock()
 				data.Data[key] = app
+				result[key] = appFailed
 				return
 			}
 
 			completeTemplate, err := builder.BuildTemplate(scheme, template)
 			if err != nil {
+				setStatus(appFailed)
 				return
 			}
 
-			if app.Rewrite {
-				err = os.WriteFile(app.Path, []byte(completeTemplate), 0666)
-				if err != nil {
-					return
-				}
-			}
+			output := completeTemplate
+			configFileData, err := os.ReadFile(app.Path)
 
 			if !app.Rewrite {
-				configFileData, err := os.ReadFile(app.Path)
 				if err != nil {
 					app.Active = false
 					app.Path = ""
 					data.mu.Lock()
 					defer data.mu.Unlock()
 					data.Data[key] = app
+					result[key] = appFailed
 					return
 				}
 
 				updatedData := insertTemplate(string(configFileData), config.InsertStart, config.InsertEnd, completeTemplate)
+				output = strings.TrimSpace(updatedData)
+			}
 
-				err = os.WriteFile(app.Path, []byte(strings.TrimSpace(updatedData)), 0666)
-				if err != nil {
-					return
-				}
+			// Leave the file alone so its modified time and the app's hook
+			// aren't triggered for nothing
+			if err == nil && string(configFileData) == output {
+				setStatus(appUnchanged)
+				return
 			}
+
+			err = os.WriteFile(app.Path, []byte(output), 0666)
+			if err != nil {
+				setStatus(appFailed)
+				return
+			}
+
+			setStatus(appWritten)
 		}(key, app, &data, &wg)
 	}
 
 	wg.Wait()
 
+	for key, app := range data.Data {
+		appsMap[key] = app
+	}
+
+	if all {
+		recolorTerminalsIfEnabled(scheme)
+		updateShellInit(scheme)
+	}
+
 	wg2 := sync.WaitGroup{}
 
-	if theme.Hook != "" {
+	if all && theme.Hook != "" {
 		wg2.Add(1)
 		go func() {
 			defer wg2.Done()
@@ -174,7 +274,11 @@ func applyTheme(theme Theme) error {
 		}()
 	}
 
-	for _, app := range appsMap {
+	for key, app := range appsMap {
+		if result[key] != appWritten {
+			continue
+		}
+
 		if app.Hook != "" {
 			wg2.Add(1)
 
@@ -192,14 +296,19 @@ func applyTheme(theme Theme) error {
 
 	wg2.Wait()
 
-	err = os.WriteFile(config.Paths.ActiveTheme, []byte(theme.Path), 0666)
+	if !all {
+		WriteAppData(appsMap)
+		return result, nil
+	}
+
+	err = WriteState(State{ActiveTheme: theme.Key()})
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	WriteAppData(appsMap)
 
-	return nil
+	return result, RecordRecentTheme(theme.Key())
 }
 
 func insertTemplate(fileData, startString, endString, template string) string {
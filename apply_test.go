@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestInsertTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "replaces between markers",
+			data: "before\n# START_PIN_HERE\nold\nlines\n# END_PIN_HERE\nafter",
+			want: "before\n# START_PIN_HERE\nnew\n# END_PIN_HERE\nafter\n",
+		},
+		{
+			name: "empty block",
+			data: "# START_PIN_HERE\n# END_PIN_HERE",
+			want: "# START_PIN_HERE\nnew\n# END_PIN_HERE\n",
+		},
+		{
+			name: "no markers",
+			data: "before\nafter",
+			want: "before\nafter",
+		},
+		{
+			name: "no end marker",
+			data: "before\n# START_PIN_HERE\nold",
+			want: "before\n# START_PIN_HERE\nold",
+		},
+		{
+			name: "no start marker",
+			data: "old\n# END_PIN_HERE\nafter",
+			want: "old\n# END_PIN_HERE\nafter",
+		},
+	}
+
+	for _, test := range tests {
+		if got := insertTemplate(test.data, "START_PIN_HERE", "END_PIN_HERE", "new"); got != test.want {
+			t.Errorf("%s: insertTemplate = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestApplyThemeToAppsUnchanged(t *testing.T) {
+	root := t.TempDir()
+
+	saved := config
+	t.Cleanup(func() { config = saved })
+
+	config.Paths.Apps = filepath.Join(root, "apps.yaml")
+	config.Paths.Templates = filepath.Join(root, "templates")
+	config.DefaultShell = "sh -c"
+	config.InsertStart = "START_PIN_HERE"
+	config.InsertEnd = "END_PIN_HERE"
+
+	write := func(path string, data string) {
+		t.Helper()
+
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kittyTemplate := filepath.Join(root, "templates", "kitty", "default.mustache")
+	waybarTemplate := filepath.Join(root, "templates", "waybar", "default.mustache")
+	kittyPath := filepath.Join(root, "kitty.conf")
+	waybarPath := filepath.Join(root, "waybar.css")
+	hookLog := filepath.Join(root, "hook.log")
+
+	write(kittyTemplate, "background #{{base00-hex}}")
+	write(waybarTemplate, "@define-color fg #{{base05-hex}};")
+	write(kittyPath, "font_size 12\n# START_PIN_HERE\nbackground #000000\n# END_PIN_HERE")
+	write(waybarPath, "")
+
+	apps := map[string]App{
+		"kitty":  {Name: "kitty", Path: kittyPath, Template: kittyTemplate, Hook: "echo kitty >> " + hookLog, Active: true},
+		"waybar": {Name: "waybar", Path: waybarPath, Template: waybarTemplate, Active: true, Rewrite: true},
+		"mako":   {Name: "mako", Active: true},
+	}
+	WriteAppData(apps)
+
+	theme := Theme{Name: "Catppuccin Latte", Path: "bundled/base16/catppuccin-latte.yaml"}
+	names := []string{"kitty", "waybar", "mako"}
+
+	result, err := applyThemeToApps(theme, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result["kitty"] != appWritten || result["waybar"] != appWritten {
+		t.Errorf("first apply = %s, want kitty and waybar written", result)
+	}
+
+	if _, ok := result["mako"]; ok {
+		t.Errorf("first apply = %s, want no status for mako without a path", result)
+	}
+
+	appsData, _ := os.ReadFile(config.Paths.Apps)
+	savedApps := map[string]App{}
+	if err := yaml.Unmarshal(appsData, &savedApps); err != nil {
+		t.Fatal(err)
+	}
+
+	if savedApps["mako"].Active || !savedApps["kitty"].Active {
+		t.Errorf("saved apps = %v, want mako deactivated and kitty active", savedApps)
+	}
+
+	kitty, _ := os.ReadFile(kittyPath)
+	if string(kitty) != "font_size 12\n# START_PIN_HERE\nbackground #eff1f5\n# END_PIN_HERE" {
+		t.Errorf("kitty.conf = %q", kitty)
+	}
+
+	waybar, _ := os.ReadFile(waybarPath)
+	if string(waybar) != "@define-color fg #4c4f69;" {
+		t.Errorf("waybar.css = %q", waybar)
+	}
+
+	// Applying the same theme again leaves the files and hooks alone
+	result, err = applyThemeToApps(theme, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result["kitty"] != appUnchanged || result["waybar"] != appUnchanged {
+		t.Errorf("second apply = %s, want kitty and waybar unchanged", result)
+	}
+
+	hooks, _ := os.ReadFile(hookLog)
+	if strings.Count(string(hooks), "kitty") != 1 {
+		t.Errorf("kitty hook ran %d times, want once", strings.Count(string(hooks), "kitty"))
+	}
+
+	// Only the named apps are applied to
+	write(waybarPath, "")
+
+	result, err = applyThemeToApps(theme, []string{"kitty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := result["waybar"]; ok || result["kitty"] != appUnchanged {
+		t.Errorf("applying to kitty = %s, want only kitty unchanged", result)
+	}
+
+	if waybar, _ := os.ReadFile(waybarPath); len(waybar) != 0 {
+		t.Errorf("waybar.css was written when only kitty was named: %q", waybar)
+	}
+}
@@ -0,0 +1,155 @@
+package builder
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// A ContrastPair is a foreground and background that base16 templates draw
+// on top of each other, with the WCAG contrast ratio it should meet.
+type ContrastPair struct {
+	Name       string
+	Foreground string
+	Background string
+	Minimum    float64
+}
+
+var ContrastPairs = []ContrastPair{
+	{Name: "foreground", Foreground: "base05", Background: "base00", Minimum: 4.5},
+	{Name: "comments", Foreground: "base03", Background: "base00", Minimum: 3},
+	{Name: "selection", Foreground: "base05", Background: "base02", Minimum: 4.5},
+	{Name: "status bar", Foreground: "base04", Background: "base01", Minimum: 3},
+}
+
+type ContrastResult struct {
+	ContrastPair
+	Ratio float64
+}
+
+func (r ContrastResult) Pass() bool {
+	return r.Ratio >= r.Minimum
+}
+
+// AuditScheme calculates the contrast of each pair. A target above 0 replaces
+// the minimum of every pair.
+func AuditScheme(scheme Scheme, target float64) ([]ContrastResult, error) {
+	palette, err := parsePalette(scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []ContrastResult{}
+
+	for _, pair := range ContrastPairs {
+		if target > 0 {
+			pair.Minimum = target
+		}
+
+		results = append(results, ContrastResult{
+			ContrastPair: pair,
+			Ratio:        ContrastRatio(palette[pair.Foreground], palette[pair.Background]),
+		})
+	}
+
+	return results, nil
+}
+
+// FixContrast changes the lightness of each foreground that fails a pair by
+// the smallest amount that makes every pair it is drawn in pass. The result is
+// audited again and an error is returned when a pair still fails.
+func FixContrast(scheme Scheme, target float64) (Scheme, error) {
+	results, err := AuditScheme(scheme, target)
+	if err != nil {
+		return Scheme{}, err
+	}
+
+	palette, _ := parsePalette(scheme)
+
+	// Pairs sharing a foreground are solved together so fixing one can't break another
+	pairs := make(map[string][]ContrastResult)
+	foregrounds := []string{}
+	failing := make(map[string]bool)
+
+	for _, result := range results {
+		if _, ok := pairs[result.Foreground]; !ok {
+			foregrounds = append(foregrounds, result.Foreground)
+		}
+		pairs[result.Foreground] = append(pairs[result.Foreground], result)
+		if !result.Pass() {
+			failing[result.Foreground] = true
+		}
+	}
+
+	for _, fg := range foregrounds {
+		if !failing[fg] {
+			continue
+		}
+
+		palette[fg] = increaseContrast(palette[fg], pairs[fg], palette)
+	}
+
+	fixed := scheme
+	fixed.Slug = ""
+	fixed.Palette = make(map[string]string)
+
+	for _, key := range PaletteKeys(scheme) {
+		fixed.Palette[key] = HexColor(palette[key])
+	}
+
+	results, err = AuditScheme(fixed, target)
+	if err != nil {
+		return Scheme{}, err
+	}
+
+	failed := []string{}
+	for _, result := range results {
+		if !result.Pass() {
+			failed = append(failed, fmt.Sprintf("%s %.2f:1", result.Name, result.Ratio))
+		}
+	}
+
+	if len(failed) > 0 {
+		return Scheme{}, fmt.Errorf("Contrast can't be fixed for %s", strings.Join(failed, ", "))
+	}
+
+	return fixed, nil
+}
+
+// increaseContrast searches outwards from the lightness of the foreground for
+// the closest lightness where every pair passes. The foreground is returned
+// unchanged when there is none.
+func increaseContrast(fg color.RGBA, pairs []ContrastResult, palette map[string]color.RGBA) color.RGBA {
+	h, s, l := RGBToHSL(fg)
+
+	passes := func(c color.RGBA) bool {
+		for _, pair := range pairs {
+			if ContrastRatio(c, palette[pair.Background]) < pair.Minimum {
+				return false
+			}
+		}
+		return true
+	}
+
+	for offset := 0.0; offset <= 1; offset += 0.005 {
+		for _, candidate := range []float64{l - offset, l + offset} {
+			if candidate < 0 || candidate > 1 {
+				continue
+			}
+
+			c := HSLToRGB(h, s, candidate)
+			if passes(c) {
+				return c
+			}
+		}
+	}
+
+	// Black and white are the most extreme foregrounds and may be skipped by the steps
+	for _, c := range []color.RGBA{{A: 255}, {R: 255, G: 255, B: 255, A: 255}} {
+		if passes(c) {
+			return c
+		}
+	}
+
+	return fg
+}
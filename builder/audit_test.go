@@ -0,0 +1,96 @@
+package builder
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestAuditScheme(t *testing.T) {
+	results, err := AuditScheme(testScheme("Nord", testDarkPalette), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"foreground": true, "comments": false, "selection": true, "status bar": true}
+
+	for _, result := range results {
+		if result.Pass() != want[result.Name] {
+			t.Errorf("%s %.2f:1 pass = %v, want %v", result.Name, result.Ratio, result.Pass(), want[result.Name])
+		}
+	}
+
+	results, _ = AuditScheme(testScheme("Nord", testDarkPalette), 21)
+	for _, result := range results {
+		if result.Minimum != 21 || result.Pass() {
+			t.Errorf("%s with target 21 = minimum %v pass %v, want minimum 21 and fail", result.Name, result.Minimum, result.Pass())
+		}
+	}
+}
+
+func TestFixContrast(t *testing.T) {
+	// base05 is too dark for base00 and base02, lightening it for one pair
+	// is not enough for the other
+	lowContrast := maps.Clone(testDarkPalette)
+	lowContrast["base00"] = "#202020"
+	lowContrast["base02"] = "#505050"
+	lowContrast["base05"] = "#707070"
+	lowContrast["base03"] = "#303030"
+
+	// Fixing base05 separately would lighten it against base00 and then darken
+	// it again against base02, only a very light base05 passes both
+	opposite := maps.Clone(lowContrast)
+	opposite["base02"] = "#909090"
+
+	tests := []struct {
+		name    string
+		scheme  Scheme
+		target  float64
+		wantErr bool
+	}{
+		{name: "nord", scheme: testScheme("Nord", testDarkPalette)},
+		{name: "shared foreground", scheme: testScheme("Low", lowContrast)},
+		{name: "opposite directions", scheme: testScheme("Opposite", opposite), target: 3},
+		{name: "target 7", scheme: testScheme("Low", lowContrast), target: 7},
+		{name: "base24", scheme: testBase24Scheme(), target: 4.5},
+		{name: "impossible target", scheme: testScheme("Low", lowContrast), target: 21, wantErr: true},
+	}
+
+	for _, test := range tests {
+		fixed, err := FixContrast(test.scheme, test.target)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: FixContrast should fail", test.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: FixContrast returned %v", test.name, err)
+			continue
+		}
+
+		results, err := AuditScheme(fixed, test.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, result := range results {
+			if !result.Pass() {
+				t.Errorf("%s: %s is %.2f:1 after fixing, want %.1f:1", test.name, result.Name, result.Ratio, result.Minimum)
+			}
+		}
+
+		// Only foregrounds change and every key of the system is kept
+		for _, key := range PaletteKeys(test.scheme) {
+			c, _ := ParseHexColor(test.scheme.Palette[key])
+			original := HexColor(c)
+
+			switch {
+			case fixed.Palette[key] == "":
+				t.Errorf("%s: %s is missing after fixing", test.name, key)
+			case key != "base03" && key != "base04" && key != "base05" && fixed.Palette[key] != original:
+				t.Errorf("%s: %s changed from %s to %s", test.name, key, original, fixed.Palette[key])
+			}
+		}
+	}
+}
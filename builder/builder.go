@@ -15,9 +15,9 @@ import (
 type Scheme struct {
 	System      string            `yaml:"system"`
 	Name        string            `yaml:"name"`
-	Slug        string            `yaml:"slug"`
+	Slug        string            `yaml:"slug,omitempty"`
 	Author      string            `yaml:"author"`
-	Description string            `yaml:"description"`
+	Description string            `yaml:"description,omitempty"`
 	Variant     string            `yaml:"variant"`
 	Palette     map[string]string `yaml:"palette"`
 }
@@ -31,22 +31,29 @@ func BuildTemplate(scheme Scheme, template []byte) (string, error) {
 	templateVars["scheme-slug"] = scheme.Slug
 	templateVars["scheme-slug-underscored"] = strings.ReplaceAll(scheme.Slug, "-", "_")
 	templateVars["scheme-system"] = scheme.System
-	templateVars["scheme-variant"] = scheme.Variant
+	if !validScheme(scheme) {
+		return "", errors.New("Invalid Scheme")
+	}
 
-	if scheme.Variant != "" {
-		templateVars["scheme-is-"+scheme.Variant+"-variant"] = true
+	// Schemes without a variant get the one their palette looks like
+	variant := scheme.Variant
+	if variant == "" {
+		inferred, err := InferVariant(scheme)
+		if err != nil {
+			return "", err
+		}
+		variant = inferred
 	}
 
+	templateVars["scheme-variant"] = variant
+	templateVars["scheme-is-"+variant+"-variant"] = true
+
 	if scheme.Slug == "" {
 		newSlug := slug.Make(scheme.Name)
 		templateVars["scheme-slug"] = newSlug
 		templateVars["scheme-slug-underscored"] = strings.ReplaceAll(newSlug, "-", "_")
 	}
 
-	if !validScheme(scheme) {
-		return "", errors.New("Invalid Scheme")
-	}
-
 	for key, clrString := range scheme.Palette {
 		c, err := ParseHexColor(clrString)
 		if err != nil {
@@ -75,9 +82,7 @@ func BuildTemplate(scheme Scheme, template []byte) (string, error) {
 }
 
 func ParseHexColor(hexColor string) (color.RGBA, error) {
-	if hexColor[0] == '#' {
-		hexColor = hexColor[1:]
-	}
+	hexColor = strings.TrimPrefix(hexColor, "#")
 
 	re := regexp.MustCompile("^[0-9a-fA-F]{3}$|^[0-9a-fA-F]{6}$")
 
@@ -112,27 +117,40 @@ func doubleEachChar(input string) string {
 	return result
 }
 
-func validScheme(scheme Scheme) bool {
-	keys := []string{
-		"base00",
-		"base01",
-		"base02",
-		"base03",
-		"base04",
-		"base05",
-		"base06",
-		"base07",
-		"base08",
-		"base09",
-		"base0A",
-		"base0B",
-		"base0C",
-		"base0D",
-		"base0E",
-		"base0F",
-	}
+// Base16Keys are the palette keys of a base16 scheme in order.
+var Base16Keys = []string{
+	"base00",
+	"base01",
+	"base02",
+	"base03",
+	"base04",
+	"base05",
+	"base06",
+	"base07",
+	"base08",
+	"base09",
+	"base0A",
+	"base0B",
+	"base0C",
+	"base0D",
+	"base0E",
+	"base0F",
+}
+
+// Base24Keys are the palette keys a base24 scheme adds to the base16 ones.
+var Base24Keys = []string{
+	"base10",
+	"base11",
+	"base12",
+	"base13",
+	"base14",
+	"base15",
+	"base16",
+	"base17",
+}
 
-	for _, key := range keys {
+func validScheme(scheme Scheme) bool {
+	for _, key := range Base16Keys {
 		if _, exists := scheme.Palette[key]; !exists {
 			return false
 		}
@@ -0,0 +1,34 @@
+package builder
+
+import "testing"
+
+func TestBuildTemplateVariant(t *testing.T) {
+	template := []byte("{{scheme-variant}} dark={{#scheme-is-dark-variant}}yes{{/scheme-is-dark-variant}} light={{#scheme-is-light-variant}}yes{{/scheme-is-light-variant}}")
+
+	tests := []struct {
+		variant string
+		want    string
+	}{
+		// Only an empty variant is inferred from the dark palette
+		{variant: "", want: "dark dark=yes light="},
+		{variant: "dark", want: "dark dark=yes light="},
+		{variant: "light", want: "light dark= light=yes"},
+		{variant: "Dark", want: "Dark dark= light="},
+		{variant: "dusk", want: "dusk dark= light="},
+	}
+
+	for _, test := range tests {
+		scheme := testScheme("Nord", testDarkPalette)
+		scheme.Variant = test.variant
+
+		got, err := BuildTemplate(scheme, template)
+		if err != nil {
+			t.Errorf("variant %q: BuildTemplate returned %v", test.variant, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("variant %q: BuildTemplate = %q, want %q", test.variant, got, test.want)
+		}
+	}
+}
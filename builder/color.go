@@ -0,0 +1,153 @@
+package builder
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+func HexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// RGBToHSL returns the hue in degrees and the saturation and lightness between 0 and 1.
+func RGBToHSL(c color.RGBA) (float64, float64, float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	l := (maxC + minC) / 2
+
+	if maxC == minC {
+		return 0, 0, l
+	}
+
+	d := maxC - minC
+
+	s := d / (2 - maxC - minC)
+	if l <= 0.5 {
+		s = d / (maxC + minC)
+	}
+
+	var h float64
+	switch maxC {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+
+	return h * 60, s, l
+}
+
+func HSLToRGB(h, s, l float64) color.RGBA {
+	h = math.Mod(math.Mod(h, 360)+360, 360)
+	s = clamp(s, 0, 1)
+	l = clamp(l, 0, 1)
+
+	chroma := (1 - math.Abs(2*l-1)) * s
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - chroma/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = chroma, x, 0
+	case h < 120:
+		r, g, b = x, chroma, 0
+	case h < 180:
+		r, g, b = 0, chroma, x
+	case h < 240:
+		r, g, b = 0, x, chroma
+	case h < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	return color.RGBA{R: toByte(r + m), G: toByte(g + m), B: toByte(b + m), A: 255}
+}
+
+func toByte(v float64) uint8 {
+	return uint8(math.Round(clamp(v, 0, 1) * 255))
+}
+
+func clamp(v, low, high float64) float64 {
+	return math.Min(math.Max(v, low), high)
+}
+
+// RelativeLuminance is the WCAG relative luminance of the colour.
+func RelativeLuminance(c color.RGBA) float64 {
+	return 0.2126*toLinear(c.R) + 0.7152*toLinear(c.G) + 0.0722*toLinear(c.B)
+}
+
+// ContrastRatio is the WCAG contrast ratio between two colours, from 1 to 21.
+func ContrastRatio(a, b color.RGBA) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+
+	return (la + 0.05) / (lb + 0.05)
+}
+
+type OKLab struct {
+	L, A, B float64
+}
+
+func toLinear(v uint8) float64 {
+	s := float64(v) / 255
+	if s <= 0.04045 {
+		return s / 12.92
+	}
+	return math.Pow((s+0.055)/1.055, 2.4)
+}
+
+func fromLinear(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// RGBToOKLab converts a colour to the perceptual OKLab colour space.
+func RGBToOKLab(c color.RGBA) OKLab {
+	r, g, b := toLinear(c.R), toLinear(c.G), toLinear(c.B)
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+func OKLabToRGB(lab OKLab) color.RGBA {
+	l := lab.L + 0.3963377774*lab.A + 0.2158037573*lab.B
+	m := lab.L - 0.1055613458*lab.A - 0.0638541728*lab.B
+	s := lab.L - 0.0894841775*lab.A - 1.2914855480*lab.B
+
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	r := 4.0767416621*l - 3.3077115913*m + 0.2309699292*s
+	g := -1.2684380046*l + 2.6097574011*m - 0.3413193965*s
+	b := -0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+
+	return color.RGBA{R: toByte(fromLinear(r)), G: toByte(fromLinear(g)), B: toByte(fromLinear(b)), A: 255}
+}
+
+// MixColors interpolates between two colours in OKLab, ratio 0 is a and 1 is b.
+func MixColors(a, b color.RGBA, ratio float64) color.RGBA {
+	la, lb := RGBToOKLab(a), RGBToOKLab(b)
+
+	return OKLabToRGB(OKLab{
+		L: la.L + (lb.L-la.L)*ratio,
+		A: la.A + (lb.A-la.A)*ratio,
+		B: la.B + (lb.B-la.B)*ratio,
+	})
+}
@@ -0,0 +1,147 @@
+package builder
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func rgb(r, g, b uint8) color.RGBA {
+	return color.RGBA{R: r, G: g, B: b, A: 255}
+}
+
+func TestRGBToHSL(t *testing.T) {
+	tests := []struct {
+		c       color.RGBA
+		h, s, l float64
+	}{
+		{c: rgb(0, 0, 0), h: 0, s: 0, l: 0},
+		{c: rgb(255, 255, 255), h: 0, s: 0, l: 1},
+		{c: rgb(128, 128, 128), h: 0, s: 0, l: 0.502},
+		{c: rgb(255, 0, 0), h: 0, s: 1, l: 0.5},
+		{c: rgb(0, 255, 0), h: 120, s: 1, l: 0.5},
+		{c: rgb(0, 0, 255), h: 240, s: 1, l: 0.5},
+		{c: rgb(255, 0, 255), h: 300, s: 1, l: 0.5},
+		{c: rgb(0x88, 0xc0, 0xd0), h: 193.3, s: 0.434, l: 0.675},
+	}
+
+	for _, test := range tests {
+		h, s, l := RGBToHSL(test.c)
+
+		if math.Abs(h-test.h) > 0.1 || math.Abs(s-test.s) > 0.001 || math.Abs(l-test.l) > 0.001 {
+			t.Errorf("RGBToHSL(%s) = %.1f, %.3f, %.3f, want %.1f, %.3f, %.3f", HexColor(test.c), h, s, l, test.h, test.s, test.l)
+		}
+	}
+}
+
+func TestHSLToRGB(t *testing.T) {
+	tests := []struct {
+		h, s, l float64
+		want    color.RGBA
+	}{
+		{h: 0, s: 1, l: 0.5, want: rgb(255, 0, 0)},
+		{h: 360, s: 1, l: 0.5, want: rgb(255, 0, 0)},
+		{h: -120, s: 1, l: 0.5, want: rgb(0, 0, 255)},
+		{h: 60, s: 1, l: 0.5, want: rgb(255, 255, 0)},
+		{h: 180, s: 0.5, l: 0.25, want: rgb(32, 96, 96)},
+		{h: 90, s: 0, l: 0.5, want: rgb(128, 128, 128)},
+		{h: 0, s: 2, l: -1, want: rgb(0, 0, 0)},
+		{h: 0, s: 1, l: 2, want: rgb(255, 255, 255)},
+	}
+
+	for _, test := range tests {
+		if got := HSLToRGB(test.h, test.s, test.l); got != test.want {
+			t.Errorf("HSLToRGB(%v, %v, %v) = %s, want %s", test.h, test.s, test.l, HexColor(got), HexColor(test.want))
+		}
+	}
+}
+
+func TestHSLRoundTrip(t *testing.T) {
+	for r := 0; r < 256; r += 15 {
+		for g := 0; g < 256; g += 15 {
+			for b := 0; b < 256; b += 15 {
+				c := rgb(uint8(r), uint8(g), uint8(b))
+
+				if got := HSLToRGB(RGBToHSL(c)); got != c {
+					t.Fatalf("HSL round trip of %s = %s", HexColor(c), HexColor(got))
+				}
+			}
+		}
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		a, b color.RGBA
+		want float64
+	}{
+		{a: rgb(0, 0, 0), b: rgb(255, 255, 255), want: 21},
+		{a: rgb(255, 255, 255), b: rgb(0, 0, 0), want: 21},
+		{a: rgb(0x77, 0x77, 0x77), b: rgb(255, 255, 255), want: 4.48},
+		{a: rgb(0x12, 0x34, 0x56), b: rgb(0x12, 0x34, 0x56), want: 1},
+	}
+
+	for _, test := range tests {
+		if got := ContrastRatio(test.a, test.b); math.Abs(got-test.want) > 0.01 {
+			t.Errorf("ContrastRatio(%s, %s) = %.2f, want %.2f", HexColor(test.a), HexColor(test.b), got, test.want)
+		}
+	}
+}
+
+func TestOKLab(t *testing.T) {
+	// Reference values from the OKLab definition
+	tests := []struct {
+		c    color.RGBA
+		want OKLab
+	}{
+		{c: rgb(255, 255, 255), want: OKLab{L: 1, A: 0, B: 0}},
+		{c: rgb(0, 0, 0), want: OKLab{L: 0, A: 0, B: 0}},
+		{c: rgb(255, 0, 0), want: OKLab{L: 0.628, A: 0.225, B: 0.126}},
+		{c: rgb(0, 255, 0), want: OKLab{L: 0.866, A: -0.234, B: 0.179}},
+		{c: rgb(0, 0, 255), want: OKLab{L: 0.452, A: -0.032, B: -0.312}},
+	}
+
+	for _, test := range tests {
+		got := RGBToOKLab(test.c)
+
+		if math.Abs(got.L-test.want.L) > 0.001 || math.Abs(got.A-test.want.A) > 0.001 || math.Abs(got.B-test.want.B) > 0.001 {
+			t.Errorf("RGBToOKLab(%s) = %.3f, want %.3f", HexColor(test.c), got, test.want)
+		}
+	}
+}
+
+func TestOKLabRoundTrip(t *testing.T) {
+	for r := 0; r < 256; r += 15 {
+		for g := 0; g < 256; g += 15 {
+			for b := 0; b < 256; b += 15 {
+				c := rgb(uint8(r), uint8(g), uint8(b))
+
+				if got := OKLabToRGB(RGBToOKLab(c)); got != c {
+					t.Fatalf("OKLab round trip of %s = %s", HexColor(c), HexColor(got))
+				}
+			}
+		}
+	}
+}
+
+func TestMixColors(t *testing.T) {
+	black, white := rgb(0, 0, 0), rgb(255, 255, 255)
+
+	tests := []struct {
+		a, b  color.RGBA
+		ratio float64
+		want  color.RGBA
+	}{
+		{a: black, b: white, ratio: 0, want: black},
+		{a: black, b: white, ratio: 1, want: white},
+		// Half way in OKLab is perceptually mid grey rather than #808080
+		{a: black, b: white, ratio: 0.5, want: rgb(99, 99, 99)},
+		{a: rgb(0x2e, 0x34, 0x40), b: rgb(0x2e, 0x34, 0x40), ratio: 0.3, want: rgb(0x2e, 0x34, 0x40)},
+	}
+
+	for _, test := range tests {
+		if got := MixColors(test.a, test.b, test.ratio); got != test.want {
+			t.Errorf("MixColors(%s, %s, %v) = %s, want %s", HexColor(test.a), HexColor(test.b), test.ratio, HexColor(got), HexColor(test.want))
+		}
+	}
+}
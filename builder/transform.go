@@ -0,0 +1,179 @@
+package builder
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+)
+
+// The contrast accents are adjusted towards when a scheme is inverted.
+const invertAccentContrast = 3.0
+
+// PaletteKeys are the palette keys of the scheme's system in order.
+func PaletteKeys(scheme Scheme) []string {
+	if scheme.System == "base24" {
+		return append(slices.Clone(Base16Keys), Base24Keys...)
+	}
+
+	return Base16Keys
+}
+
+func parsePalette(scheme Scheme) (map[string]color.RGBA, error) {
+	if !validScheme(scheme) {
+		return nil, errors.New("Invalid Scheme")
+	}
+
+	palette := make(map[string]color.RGBA)
+
+	for _, key := range PaletteKeys(scheme) {
+		c, err := ParseHexColor(scheme.Palette[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		palette[key] = c
+	}
+
+	return palette, nil
+}
+
+// InferVariant works out whether a scheme is dark or light by comparing the
+// luminance of the background (base00) and foreground (base05).
+func InferVariant(scheme Scheme) (string, error) {
+	background, err := ParseHexColor(scheme.Palette["base00"])
+	if err != nil {
+		return "", fmt.Errorf("base00: %w", err)
+	}
+
+	foreground, err := ParseHexColor(scheme.Palette["base05"])
+	if err != nil {
+		return "", fmt.Errorf("base05: %w", err)
+	}
+
+	if RelativeLuminance(background) > RelativeLuminance(foreground) {
+		return "light", nil
+	}
+
+	return "dark", nil
+}
+
+// InvertScheme creates the opposite variant of a scheme. The base00 to base07
+// ramp is reversed and each accent has its lightness flipped, then moved away
+// from the new background until it is readable. The extra base24 backgrounds
+// (base10 and base11) have their lightness flipped and the bright accents are
+// treated like the others.
+func InvertScheme(scheme Scheme) (Scheme, error) {
+	palette, err := parsePalette(scheme)
+	if err != nil {
+		return Scheme{}, err
+	}
+
+	variant := scheme.Variant
+	if variant != "dark" && variant != "light" {
+		variant, _ = InferVariant(scheme)
+	}
+
+	inverted := scheme
+	inverted.Slug = ""
+	inverted.Variant = "light"
+	if variant == "light" {
+		inverted.Variant = "dark"
+	}
+	inverted.Palette = make(map[string]string)
+
+	name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(scheme.Name, " Dark"), " Light"))
+	inverted.Name = name + " " + strings.ToUpper(inverted.Variant[:1]) + inverted.Variant[1:]
+
+	// The ramp is reversed by luminance as some schemes use base07 for an accent
+	ramp := []color.RGBA{}
+	for _, key := range Base16Keys[:8] {
+		ramp = append(ramp, palette[key])
+	}
+
+	slices.SortStableFunc(ramp, func(a, b color.RGBA) int {
+		if inverted.Variant == "light" {
+			return cmp.Compare(RelativeLuminance(b), RelativeLuminance(a))
+		}
+		return cmp.Compare(RelativeLuminance(a), RelativeLuminance(b))
+	})
+
+	for i, c := range ramp {
+		inverted.Palette[Base16Keys[i]] = HexColor(c)
+	}
+
+	background := ramp[0]
+
+	accents := slices.Clone(Base16Keys[8:])
+	if scheme.System == "base24" {
+		for _, key := range Base24Keys[:2] {
+			h, s, l := RGBToHSL(palette[key])
+			inverted.Palette[key] = HexColor(HSLToRGB(h, s, 1-l))
+		}
+		accents = append(accents, Base24Keys[2:]...)
+	}
+
+	for _, key := range accents {
+		inverted.Palette[key] = HexColor(invertAccent(palette[key], background, inverted.Variant))
+	}
+
+	return inverted, nil
+}
+
+func invertAccent(accent, background color.RGBA, variant string) color.RGBA {
+	h, s, l := RGBToHSL(accent)
+	l = 1 - l
+
+	// Darken accents on light backgrounds and lighten them on dark ones
+	step := -0.02
+	if variant == "dark" {
+		step = 0.02
+	}
+
+	c := HSLToRGB(h, s, l)
+	for ContrastRatio(c, background) < invertAccentContrast && l > 0 && l < 1 {
+		l = clamp(l+step, 0, 1)
+		c = HSLToRGB(h, s, l)
+	}
+
+	return c
+}
+
+// BlendSchemes interpolates every palette slot between two schemes in OKLab,
+// ratio 0 gives a and 1 gives b.
+func BlendSchemes(a, b Scheme, ratio float64) (Scheme, error) {
+	if ratio < 0 || ratio > 1 {
+		return Scheme{}, errors.New("Ratio must be between 0 and 1")
+	}
+
+	paletteA, err := parsePalette(a)
+	if err != nil {
+		return Scheme{}, fmt.Errorf("%s: %w", a.Name, err)
+	}
+
+	paletteB, err := parsePalette(b)
+	if err != nil {
+		return Scheme{}, fmt.Errorf("%s: %w", b.Name, err)
+	}
+
+	blended := Scheme{
+		System:      "base16",
+		Name:        fmt.Sprintf("%s / %s %.0f%%", a.Name, b.Name, ratio*100),
+		Author:      a.Author,
+		Description: fmt.Sprintf("%.0f%% %s blended with %s", (1-ratio)*100, a.Name, b.Name),
+		Palette:     make(map[string]string),
+	}
+
+	if ratio > 0.5 {
+		blended.Author = b.Author
+	}
+
+	for _, key := range Base16Keys {
+		blended.Palette[key] = HexColor(MixColors(paletteA[key], paletteB[key], ratio))
+	}
+
+	blended.Variant, _ = InferVariant(blended)
+
+	return blended, nil
+}
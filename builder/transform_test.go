@@ -0,0 +1,186 @@
+package builder
+
+import (
+	"maps"
+	"math"
+	"testing"
+)
+
+// Nord, a dark base16 scheme
+var testDarkPalette = map[string]string{
+	"base00": "#2e3440", "base01": "#3b4252", "base02": "#434c5e", "base03": "#4c566a",
+	"base04": "#d8dee9", "base05": "#e5e9f0", "base06": "#eceff4", "base07": "#8fbcbb",
+	"base08": "#bf616a", "base09": "#d08770", "base0A": "#ebcb8b", "base0B": "#a3be8c",
+	"base0C": "#88c0d0", "base0D": "#81a1c1", "base0E": "#b48ead", "base0F": "#5e81ac",
+}
+
+func testScheme(name string, palette map[string]string) Scheme {
+	return Scheme{System: "base16", Name: name, Variant: "dark", Palette: maps.Clone(palette)}
+}
+
+func testBase24Scheme() Scheme {
+	scheme := testScheme("Nord 24", testDarkPalette)
+	scheme.System = "base24"
+
+	extra := []string{"#242933", "#1d2129", "#d57780", "#e3c08d", "#b1d196", "#93ccdc", "#a3bfe3", "#c895bf"}
+	for i, key := range Base24Keys {
+		scheme.Palette[key] = extra[i]
+	}
+
+	return scheme
+}
+
+func mustParse(t *testing.T, hex string) (h, s, l float64) {
+	t.Helper()
+
+	c, err := ParseHexColor(hex)
+	if err != nil {
+		t.Fatalf("%q: %v", hex, err)
+	}
+
+	return RGBToHSL(c)
+}
+
+func TestInvertScheme(t *testing.T) {
+	scheme := testScheme("Nord Dark", testDarkPalette)
+
+	inverted, err := InvertScheme(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inverted.Variant != "light" || inverted.Name != "Nord Light" || inverted.System != "base16" {
+		t.Errorf("InvertScheme = %q %s %s, want Nord Light light base16", inverted.Name, inverted.Variant, inverted.System)
+	}
+
+	if len(inverted.Palette) != len(Base16Keys) {
+		t.Errorf("InvertScheme palette has %d keys, want %d", len(inverted.Palette), len(Base16Keys))
+	}
+
+	// The ramp is sorted light to dark, so the old foreground becomes the background
+	background, _ := ParseHexColor(inverted.Palette["base00"])
+	for i := 1; i < 8; i++ {
+		c, _ := ParseHexColor(inverted.Palette[Base16Keys[i]])
+		if RelativeLuminance(c) > RelativeLuminance(background) {
+			t.Errorf("%s is lighter than the new background", Base16Keys[i])
+		}
+	}
+
+	if inverted.Palette["base00"] != "#eceff4" {
+		t.Errorf("base00 = %s, want the lightest colour of the ramp #eceff4", inverted.Palette["base00"])
+	}
+
+	for _, key := range Base16Keys[8:] {
+		c, _ := ParseHexColor(inverted.Palette[key])
+		if ratio := ContrastRatio(c, background); ratio < invertAccentContrast {
+			t.Errorf("%s contrast = %.2f, want at least %.1f", key, ratio, invertAccentContrast)
+		}
+	}
+
+	back, err := InvertScheme(inverted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if back.Variant != "dark" || back.Name != "Nord Dark" {
+		t.Errorf("inverting twice = %q %s, want Nord Dark dark", back.Name, back.Variant)
+	}
+}
+
+func TestInvertSchemeBase24(t *testing.T) {
+	scheme := testBase24Scheme()
+
+	inverted, err := InvertScheme(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inverted.System != "base24" {
+		t.Errorf("System = %s, want base24", inverted.System)
+	}
+
+	for _, key := range PaletteKeys(scheme) {
+		if _, err := ParseHexColor(inverted.Palette[key]); err != nil {
+			t.Errorf("%s = %q: %v", key, inverted.Palette[key], err)
+		}
+	}
+
+	// The extra backgrounds have their lightness flipped
+	for _, key := range Base24Keys[:2] {
+		_, _, before := mustParse(t, scheme.Palette[key])
+		_, _, after := mustParse(t, inverted.Palette[key])
+
+		if math.Abs(after-(1-before)) > 0.01 {
+			t.Errorf("%s lightness = %.3f, want %.3f", key, after, 1-before)
+		}
+	}
+}
+
+func TestInvertSchemeInvalid(t *testing.T) {
+	palette := maps.Clone(testDarkPalette)
+	delete(palette, "base0F")
+
+	if _, err := InvertScheme(testScheme("Broken", palette)); err == nil {
+		t.Error("InvertScheme of a scheme without base0F should fail")
+	}
+
+	scheme := testBase24Scheme()
+	delete(scheme.Palette, "base17")
+
+	if _, err := InvertScheme(scheme); err == nil {
+		t.Error("InvertScheme of a base24 scheme without base17 should fail")
+	}
+}
+
+func TestBlendSchemes(t *testing.T) {
+	dark := testScheme("Dark", testDarkPalette)
+	dark.Author = "A"
+
+	light := testScheme("Light", map[string]string{})
+	light.Author = "B"
+	for _, key := range Base16Keys {
+		light.Palette[key] = "#ffffff"
+	}
+	light.Palette["base05"] = "#000000"
+
+	tests := []struct {
+		ratio   float64
+		base00  string
+		author  string
+		variant string
+	}{
+		{ratio: 0, base00: "#2e3440", author: "A", variant: "dark"},
+		{ratio: 1, base00: "#ffffff", author: "B", variant: "light"},
+		{ratio: 0.75, author: "B"},
+	}
+
+	for _, test := range tests {
+		blended, err := BlendSchemes(dark, light, test.ratio)
+		if err != nil {
+			t.Errorf("BlendSchemes(%v) returned %v", test.ratio, err)
+			continue
+		}
+
+		if len(blended.Palette) != len(Base16Keys) || blended.System != "base16" {
+			t.Errorf("BlendSchemes(%v) has %d %s colours, want 16 base16 colours", test.ratio, len(blended.Palette), blended.System)
+		}
+
+		if test.base00 != "" && blended.Palette["base00"] != test.base00 {
+			t.Errorf("BlendSchemes(%v) base00 = %s, want %s", test.ratio, blended.Palette["base00"], test.base00)
+		}
+
+		if blended.Author != test.author {
+			t.Errorf("BlendSchemes(%v) author = %s, want %s", test.ratio, blended.Author, test.author)
+		}
+
+		if test.variant != "" && blended.Variant != test.variant {
+			t.Errorf("BlendSchemes(%v) variant = %s, want %s", test.ratio, blended.Variant, test.variant)
+		}
+	}
+
+	for _, ratio := range []float64{-0.1, 1.5} {
+		if _, err := BlendSchemes(dark, light, ratio); err == nil {
+			t.Errorf("BlendSchemes(%v) should fail", ratio)
+		}
+	}
+}
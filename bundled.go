@@ -0,0 +1,31 @@
+package main
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const bundledSource = "bundled"
+
+// A snapshot of the base16 and base24 schemes from tinted-theming so pin has
+// themes before anything is fetched, refreshed by go generate. Fetched and
+// custom themes with the same name take precedence.
+//
+//go:generate sh bundled/update.sh
+//go:embed bundled/*/*.yaml
+var bundledSchemes embed.FS
+
+func isBundledPath(path string) bool {
+	return !filepath.IsAbs(path) && strings.HasPrefix(path, bundledSource+"/")
+}
+
+// ReadSchemeFile reads a scheme from disk or from the bundled schemes.
+func ReadSchemeFile(path string) ([]byte, error) {
+	if isBundledPath(path) {
+		return bundledSchemes.ReadFile(path)
+	}
+
+	return os.ReadFile(path)
+}
@@ -0,0 +1,16 @@
+#!/bin/sh
+# Replaces the bundled schemes with every base16 and base24 scheme from the
+# tinted-theming schemes repository, removing schemes that were dropped.
+set -e
+
+dir=$(dirname "$0")
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+git clone --depth 1 https://github.com/tinted-theming/schemes.git "$tmp/schemes"
+
+for system in base16 base24; do
+	rm -rf "${dir:?}/$system"
+	mkdir -p "$dir/$system"
+	cp "$tmp/schemes/$system"/*.yaml "$dir/$system/"
+done
@@ -0,0 +1,969 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ClaraSmyth/pin/builder"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/gosimple/slug"
+)
+
+func runCli(args []string) error {
+	var err error
+
+	switch args[0] {
+	case "list":
+		err = listCmd(args[1:])
+	case "random":
+		err = randomCmd(args[1:])
+	case "next":
+		err = stepCmd("next", 1, args[1:])
+	case "prev":
+		err = stepCmd("prev", -1, args[1:])
+	case "fetch":
+		err = fetchCmd()
+	case "schedule":
+		err = scheduleCmd(args[1:])
+	case "scheme":
+		err = schemeCmd(args[1:])
+	case "export":
+		err = exportCmd(args[1:])
+	case "preview":
+		err = previewCmd(args[1:])
+	case "term-apply":
+		err = termApplyCmd(args[1:])
+	case "shell-init":
+		err = shellInitCmd(args[1:])
+	case "daemon":
+		err = daemonCmd(args[1:])
+	case "watch":
+		err = watchCmd()
+	default:
+		err = applyCmd(args)
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+
+	return err
+}
+
+// parseArgs parses flags that may appear before or after positional arguments.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+type themeFilterFlags struct {
+	favorites bool
+	recent    bool
+	variant   string
+	tags      stringsFlag
+}
+
+func addThemeFilterFlags(flags *flag.FlagSet) *themeFilterFlags {
+	f := &themeFilterFlags{}
+	flags.StringVar(&f.variant, "variant", "", "only themes of this variant (dark or light)")
+	flags.BoolVar(&f.favorites, "favorites", false, "only favorite themes")
+	flags.BoolVar(&f.recent, "recent", false, "only recently applied themes")
+	flags.Var(&f.tags, "tag", "only themes with this tag (repeatable)")
+	return f
+}
+
+// query converts the flags into theme query terms and appends any extra terms.
+func (f *themeFilterFlags) query(terms []string) string {
+	if f.favorites {
+		terms = append(terms, "is:favorite")
+	}
+
+	if f.recent {
+		terms = append(terms, "is:recent")
+	}
+
+	if f.variant != "" {
+		terms = append(terms, "variant:"+f.variant)
+	}
+
+	for _, tag := range f.tags {
+		terms = append(terms, "tag:"+tag)
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func applyCmd(args []string) error {
+	flags := flag.NewFlagSet("pin", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin [--terminal] <theme>")
+		flags.PrintDefaults()
+	}
+	terminal := flags.Bool("terminal", config.TerminalColors, "recolour open terminals with escape sequences")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("Expected a theme")
+	}
+
+	response, err := SendDaemonRequest(DaemonRequest{Command: "apply", Theme: positional[0], Terminal: *terminal})
+	if err == nil {
+		printApplyResult(response.Apps)
+		return nil
+	}
+
+	if !errors.Is(err, errDaemonNotRunning) {
+		return err
+	}
+
+	config.TerminalColors = *terminal
+
+	theme, found := FindTheme(positional[0])
+	if !found {
+		return fmt.Errorf("Theme %q not found", positional[0])
+	}
+
+	result, err := applyTheme(theme)
+	if err != nil {
+		return errors.New("There was an error applying this theme!")
+	}
+
+	printApplyResult(result.apps())
+
+	return nil
+}
+
+func listCmd(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin list themes [--long] [--variant variant] [--favorites] [--recent] [--tag tag] [filter...]")
+		flags.PrintDefaults()
+	}
+	filterFlags := addThemeFilterFlags(flags)
+	long := flags.Bool("long", false, "show the source, variant and author of each theme")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) == 0 || positional[0] != "themes" {
+		flags.Usage()
+		return errors.New("Nothing to list")
+	}
+
+	query := filterFlags.query(positional[1:])
+
+	response, err := SendDaemonRequest(DaemonRequest{Command: "list", Filter: query})
+	if errors.Is(err, errDaemonNotRunning) {
+		for _, item := range FilterThemes(GetThemes(), query) {
+			response.Themes = append(response.Themes, daemonTheme(item.(Theme)))
+		}
+	} else if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	for _, theme := range response.Themes {
+		if *long {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", theme.Name, theme.Key, theme.VariantLabel(), theme.Author)
+		} else {
+			fmt.Fprintln(w, theme.DisplayName)
+		}
+	}
+
+	return w.Flush()
+}
+
+func randomCmd(args []string) error {
+	flags := flag.NewFlagSet("random", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin random [--variant variant] [--favorites] [--recent] [--tag tag] [filter...]")
+		flags.PrintDefaults()
+	}
+	filterFlags := addThemeFilterFlags(flags)
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	request := DaemonRequest{Command: "random", Filter: filterFlags.query(positional)}
+
+	return selectThemeCli(request, RandomTheme)
+}
+
+func stepCmd(name string, offset int, args []string) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: pin %s [--variant variant] [--favorites] [--recent] [--tag tag] [filter...]\n", name)
+		flags.PrintDefaults()
+	}
+	filterFlags := addThemeFilterFlags(flags)
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	request := DaemonRequest{Command: "step", Filter: filterFlags.query(positional), Offset: offset}
+
+	return selectThemeCli(request, func(themes []list.Item) (Theme, bool) {
+		return StepTheme(themes, offset)
+	})
+}
+
+// selectThemeCli has the daemon pick and apply a theme from its cached themes.
+// Without the daemon every theme is loaded and pick chooses the theme instead.
+func selectThemeCli(request DaemonRequest, pick func([]list.Item) (Theme, bool)) error {
+	response, err := SendDaemonRequest(request)
+	if err == nil {
+		fmt.Fprintln(os.Stdout, response.Theme.DisplayName)
+		printApplyResult(response.Apps)
+		return nil
+	}
+
+	if !errors.Is(err, errDaemonNotRunning) {
+		return err
+	}
+
+	theme, ok := pick(FilterThemes(GetThemes(), request.Filter))
+	if !ok {
+		return errors.New("No themes match the filter")
+	}
+
+	result, err := applyTheme(theme)
+	if err != nil {
+		return errors.New("There was an error applying this theme!")
+	}
+
+	fmt.Fprintln(os.Stdout, theme.DisplayName())
+	printApplyResult(result.apps())
+
+	return nil
+}
+
+func applyThemeCli(theme Theme) error {
+	response, err := SendDaemonRequest(DaemonRequest{Command: "apply", Theme: theme.Key().String()})
+	if err == nil {
+		fmt.Fprintln(os.Stdout, theme.DisplayName())
+		printApplyResult(response.Apps)
+		return nil
+	}
+
+	if !errors.Is(err, errDaemonNotRunning) {
+		return err
+	}
+
+	result, err := applyTheme(theme)
+	if err != nil {
+		return errors.New("There was an error applying this theme!")
+	}
+
+	fmt.Fprintln(os.Stdout, theme.DisplayName())
+	printApplyResult(result.apps())
+
+	return nil
+}
+
+// printApplyResult prints the status of each app, e.g. "kitty  unchanged".
+func printApplyResult(apps map[string]string) {
+	names := make([]string, 0, len(apps))
+	for name := range apps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, apps[name])
+	}
+	w.Flush()
+}
+
+func scheduleCmd(args []string) error {
+	usage := "Usage: pin schedule run|show|service"
+
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "run":
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			<-signals
+			close(stop)
+		}()
+
+		return RunSchedule(stop, func(message string) {
+			fmt.Fprintln(os.Stdout, time.Now().Format(time.DateTime), message)
+		})
+
+	case "show":
+		now := time.Now()
+
+		current, err := config.Schedule.Current(now)
+		if err != nil {
+			return err
+		}
+
+		next, err := config.Schedule.Next(now)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "Current: %s (%s at %s)\n", current.Event.Theme, current.Event.At, current.Time.Format(time.DateTime))
+		fmt.Fprintf(os.Stdout, "Next:    %s (%s at %s)\n", next.Event.Theme, next.Event.At, next.Time.Format(time.DateTime))
+
+		return nil
+
+	case "service":
+		executable, err := os.Executable()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, strings.TrimLeft(scheduleServiceUnit, "\n"), executable)
+
+		return nil
+
+	default:
+		return errors.New(usage)
+	}
+}
+
+var scheduleServiceUnit = `
+[Unit]
+Description=pin theme scheduler
+
+[Service]
+ExecStart=%s schedule run
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`
+
+func schemeCmd(args []string) error {
+	usage := "Usage: pin scheme from-image|import|invert|blend|audit"
+
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "from-image":
+		return schemeFromImageCmd(args[1:])
+	case "import":
+		return schemeImportCmd(args[1:])
+	case "invert":
+		return schemeInvertCmd(args[1:])
+	case "blend":
+		return schemeBlendCmd(args[1:])
+	case "audit":
+		return schemeAuditCmd(args[1:])
+	default:
+		return errors.New(usage)
+	}
+}
+
+func schemeFromImageCmd(args []string) error {
+	flags := flag.NewFlagSet("from-image", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin scheme from-image [--name name] [--variant dark|light] [--apply] <file>")
+		flags.PrintDefaults()
+	}
+	name := flags.String("name", "", "name of the new theme (defaults to the image name)")
+	variant := flags.String("variant", "", "dark or light (defaults to the lightness of the image)")
+	apply := flags.Bool("apply", false, "apply the theme once it is created")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("Expected one image file")
+	}
+
+	file := positional[0]
+
+	if *name == "" {
+		*name = slug.Make(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+	}
+
+	scheme, err := SchemeFromImage(file, *name, *variant)
+	if err != nil {
+		return err
+	}
+
+	return saveSchemeCli(*name, scheme, *apply)
+}
+
+func schemeImportCmd(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin scheme import [--format format] [--name name] [--apply] <file>")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "", "one of "+strings.Join(importFormats, ", ")+" (detected from the file by default)")
+	name := flags.String("name", "", "name of the new theme (defaults to the scheme or file name)")
+	apply := flags.Bool("apply", false, "apply the theme once it is imported")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("Expected one file")
+	}
+
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+
+	scheme, err := ImportScheme(positional[0], data, *format)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		*name = slug.Make(scheme.Name)
+	}
+
+	return saveSchemeCli(*name, scheme, *apply)
+}
+
+func schemeInvertCmd(args []string) error {
+	flags := flag.NewFlagSet("invert", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin scheme invert [--name name] [--apply] <theme>")
+		flags.PrintDefaults()
+	}
+	name := flags.String("name", "", "name of the new theme (defaults to the theme name with the new variant)")
+	apply := flags.Bool("apply", false, "apply the theme once it is created")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("Expected one theme")
+	}
+
+	theme, found := FindTheme(positional[0])
+	if !found {
+		return fmt.Errorf("Theme %q not found", positional[0])
+	}
+
+	scheme, err := ReadThemeScheme(theme)
+	if err != nil {
+		return err
+	}
+
+	scheme, err = builder.InvertScheme(scheme)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		*name = invertedName(theme.Name, scheme.Variant)
+	}
+
+	return saveSchemeCli(*name, scheme, *apply)
+}
+
+func schemeBlendCmd(args []string) error {
+	flags := flag.NewFlagSet("blend", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin scheme blend [--ratio ratio] [--name name] [--apply] <theme> <theme>")
+		flags.PrintDefaults()
+	}
+	ratio := flags.Float64("ratio", 0.5, "how far to blend from the first theme to the second, from 0 to 1")
+	name := flags.String("name", "", "name of the new theme (defaults to both theme names and the ratio)")
+	apply := flags.Bool("apply", false, "apply the theme once it is created")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 2 {
+		flags.Usage()
+		return errors.New("Expected two themes")
+	}
+
+	schemes := []builder.Scheme{}
+	names := []string{}
+
+	for _, arg := range positional {
+		theme, found := FindTheme(arg)
+		if !found {
+			return fmt.Errorf("Theme %q not found", arg)
+		}
+
+		scheme, err := ReadThemeScheme(theme)
+		if err != nil {
+			return err
+		}
+
+		schemes = append(schemes, scheme)
+		names = append(names, slug.Make(theme.Name))
+	}
+
+	scheme, err := builder.BlendSchemes(schemes[0], schemes[1], *ratio)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		*name = fmt.Sprintf("%s-%s-%.0f", names[0], names[1], *ratio*100)
+	}
+
+	return saveSchemeCli(*name, scheme, *apply)
+}
+
+func schemeAuditCmd(args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin scheme audit [--target ratio] [--fix] [--name name] [--apply] [theme]")
+		flags.PrintDefaults()
+	}
+	target := flags.Float64("target", 0, "contrast ratio every pair must meet (defaults to the WCAG minimum of each pair)")
+	fix := flags.Bool("fix", false, "save a copy of the theme adjusted to meet the target")
+	name := flags.String("name", "", "name of the fixed theme (defaults to the theme name with -accessible)")
+	apply := flags.Bool("apply", false, "apply the fixed theme once it is created")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 1 {
+		flags.Usage()
+		return errors.New("Expected at most one theme")
+	}
+
+	query := ReadState().ActiveTheme.String()
+	if len(positional) == 1 {
+		query = positional[0]
+	}
+
+	theme, found := FindTheme(query)
+	if !found {
+		return fmt.Errorf("Theme %q not found", query)
+	}
+
+	scheme, err := ReadThemeScheme(theme)
+	if err != nil {
+		return err
+	}
+
+	results, err := builder.AuditScheme(scheme, *target)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	failures := 0
+
+	fmt.Fprintln(w, theme.DisplayName())
+
+	for _, result := range results {
+		status := "ok"
+		if !result.Pass() {
+			status = fmt.Sprintf("fail (%.1f)", result.Minimum)
+			failures++
+		}
+
+		fmt.Fprintf(w, "  %s\t%s on %s\t%.2f\t%s\n", result.Name, result.Foreground, result.Background, result.Ratio, status)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if !*fix {
+		if failures > 0 {
+			return fmt.Errorf("%d of %d pairs are below the target contrast", failures, len(results))
+		}
+		return nil
+	}
+
+	if failures == 0 {
+		return nil
+	}
+
+	scheme, err = builder.FixContrast(scheme, *target)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		*name = slug.Make(theme.Name) + "-accessible"
+	}
+
+	return saveSchemeCli(*name, scheme, *apply)
+}
+
+func exportCmd(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin export [--format format] [theme]")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "json", "one of "+strings.Join(exportFormats, ", "))
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 1 {
+		flags.Usage()
+		return errors.New("Expected at most one theme")
+	}
+
+	query := ReadState().ActiveTheme.String()
+	if len(positional) == 1 {
+		query = positional[0]
+	}
+
+	theme, found := FindTheme(query)
+	if !found {
+		return fmt.Errorf("Theme %q not found", query)
+	}
+
+	scheme, err := ReadThemeScheme(theme)
+	if err != nil {
+		return err
+	}
+
+	output, err := ExportScheme(scheme, *format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, output)
+
+	return nil
+}
+
+func previewCmd(args []string) error {
+	usage := errors.New("Usage: pin preview render|gallery")
+
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "render":
+		return previewRenderCmd(args[1:])
+	case "gallery":
+		return previewGalleryCmd(args[1:])
+	default:
+		return usage
+	}
+}
+
+func previewRenderCmd(args []string) error {
+	flags := flag.NewFlagSet("preview render", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin preview render [--format format] [--code] [--output file] <theme>")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "svg", "one of "+strings.Join(previewFormats, ", "))
+	code := flags.Bool("code", false, "add a code snippet coloured with the scheme")
+	output := flags.String("output", "", "file to write, - for stdout (defaults to the theme name with the format extension)")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return errors.New("Expected a theme")
+	}
+
+	theme, found := FindTheme(positional[0])
+	if !found {
+		return fmt.Errorf("Theme %q not found", positional[0])
+	}
+
+	scheme, err := ReadThemeScheme(theme)
+	if err != nil {
+		return err
+	}
+
+	data, err := RenderPreview(scheme, *format, *code)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = slug.Make(theme.Name) + "." + *format
+	}
+
+	return writeOutputCli(*output, data)
+}
+
+func previewGalleryCmd(args []string) error {
+	flags := flag.NewFlagSet("preview gallery", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin preview gallery [--code] [--output file] [--variant variant] [--favorites] [--recent] [--tag tag] [filter...]")
+		flags.PrintDefaults()
+	}
+	filterFlags := addThemeFilterFlags(flags)
+	code := flags.Bool("code", false, "add a code snippet coloured with each scheme")
+	output := flags.String("output", "gallery.html", "file to write, - for stdout")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	themes := []Theme{}
+	for _, item := range FilterThemes(GetThemes(), filterFlags.query(positional)) {
+		themes = append(themes, item.(Theme))
+	}
+
+	if len(themes) == 0 {
+		return errors.New("No themes match the filter")
+	}
+
+	return writeOutputCli(*output, RenderGallery(themes, *code))
+}
+
+func termApplyCmd(args []string) error {
+	flags := flag.NewFlagSet("term-apply", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin term-apply [theme]")
+		flags.PrintDefaults()
+	}
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 1 {
+		flags.Usage()
+		return errors.New("Expected at most one theme")
+	}
+
+	query := ReadState().ActiveTheme.String()
+	if len(positional) == 1 {
+		query = positional[0]
+	}
+
+	theme, found := FindTheme(query)
+	if !found {
+		return fmt.Errorf("Theme %q not found", query)
+	}
+
+	scheme, err := ReadThemeScheme(theme)
+	if err != nil {
+		return err
+	}
+
+	terminals, err := RecolorTerminals(scheme)
+	if err != nil {
+		return err
+	}
+
+	if len(terminals) == 0 {
+		return errors.New("No terminals were found to recolour")
+	}
+
+	return nil
+}
+
+func shellInitCmd(args []string) error {
+	flags := flag.NewFlagSet("shell-init", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pin shell-init [--format shell]")
+		flags.PrintDefaults()
+	}
+	format := flags.String("format", "", "one of "+strings.Join(shellFormats, ", ")+" (defaults to $SHELL)")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 0 {
+		flags.Usage()
+		return errors.New("Unexpected arguments")
+	}
+
+	if *format == "" {
+		*format = filepath.Base(os.Getenv("SHELL"))
+		if !slices.Contains(shellFormats, *format) {
+			*format = "bash"
+		}
+	}
+
+	if !slices.Contains(shellFormats, *format) {
+		return fmt.Errorf("Unknown shell %q, expected one of %s", *format, strings.Join(shellFormats, ", "))
+	}
+
+	script, err := ReadShellInit(*format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stdout, script)
+
+	return nil
+}
+
+func daemonCmd(args []string) error {
+	usage := errors.New("Usage: pin daemon [subscribe]")
+
+	if len(args) == 0 {
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			<-signals
+			close(stop)
+		}()
+
+		return RunDaemon(stop, func(message string) {
+			fmt.Fprintln(os.Stdout, time.Now().Format(time.DateTime), message)
+		})
+	}
+
+	if args[0] != "subscribe" {
+		return usage
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	return DaemonSubscribe(func(event DaemonResponse) {
+		_ = encoder.Encode(event)
+	})
+}
+
+func watchCmd() error {
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	return RunWatch(stop, func(message string) {
+		fmt.Fprintln(os.Stdout, time.Now().Format(time.DateTime), message)
+	})
+}
+
+// writeOutputCli writes data to the file and prints its path, or to stdout when the file is -.
+func writeOutputCli(path string, data []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	err := os.WriteFile(path, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, path)
+
+	return nil
+}
+
+// saveSchemeCli saves a generated scheme as a custom theme and optionally applies it.
+func saveSchemeCli(name string, scheme builder.Scheme, apply bool) error {
+	path, err := SaveCustomScheme(name, scheme)
+	if err != nil {
+		return err
+	}
+
+	if !apply {
+		fmt.Fprintln(os.Stdout, path)
+		return nil
+	}
+
+	theme, found := FindTheme(ThemeKey{Source: customSource, Slug: name}.String())
+	if !found {
+		return fmt.Errorf("Theme %q not found", name)
+	}
+
+	return applyThemeCli(theme)
+}
+
+func fetchCmd() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	changes, err := FetchSources(ctx, nil)
+
+	for _, sourceChanges := range changes {
+		if sourceChanges.Empty() {
+			fmt.Fprintf(os.Stdout, "%s: up to date\n", sourceChanges.Source)
+			continue
+		}
+
+		fmt.Fprintf(os.Stdout, "%s:\n%s\n", sourceChanges.Source, sourceChanges)
+	}
+
+	return err
+}
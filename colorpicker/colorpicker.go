@@ -0,0 +1,330 @@
+// Package colorpicker is a bubble for picking a colour with HSL and RGB
+// sliders or a hex value.
+package colorpicker
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/ClaraSmyth/pin/builder"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type field int
+
+const (
+	hueField field = iota
+	saturationField
+	lightnessField
+	redField
+	greenField
+	blueField
+	hexField
+	fieldCount
+)
+
+var fieldLabels = []string{"H", "S", "L", "R", "G", "B", "#"}
+
+// KeyMap defines key bindings for each user action.
+type KeyMap struct {
+	Next     key.Binding
+	Prev     key.Binding
+	Increase key.Binding
+	Decrease key.Binding
+	StepUp   key.Binding
+	StepDown key.Binding
+}
+
+// DefaultKeyMap defines the default keybindings.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next:     key.NewBinding(key.WithKeys("down", "tab"), key.WithHelp("↓", "next")),
+		Prev:     key.NewBinding(key.WithKeys("up", "shift+tab"), key.WithHelp("↑", "prev")),
+		Increase: key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→", "increase")),
+		Decrease: key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←", "decrease")),
+		StepUp:   key.NewBinding(key.WithKeys("shift+right", "L"), key.WithHelp("shift+→", "increase more")),
+		StepDown: key.NewBinding(key.WithKeys("shift+left", "H"), key.WithHelp("shift+←", "decrease more")),
+	}
+}
+
+// Styles defines the possible customizations for styles in the colour picker.
+type Styles struct {
+	Label        lipgloss.Style
+	FocusedLabel lipgloss.Style
+	Value        lipgloss.Style
+	Knob         lipgloss.Style
+	Text         lipgloss.Style
+	Cursor       lipgloss.Style
+}
+
+// DefaultStyles defines the default styling for the colour picker.
+func DefaultStyles() Styles {
+	return Styles{
+		Label:        lipgloss.NewStyle().Foreground(lipgloss.Color("243")),
+		FocusedLabel: lipgloss.NewStyle().Foreground(lipgloss.Color("212")),
+		Value:        lipgloss.NewStyle().Foreground(lipgloss.Color("243")),
+		Knob:         lipgloss.NewStyle().Foreground(lipgloss.Color("255")),
+		Text:         lipgloss.NewStyle(),
+		Cursor:       lipgloss.NewStyle().Foreground(lipgloss.Color("212")),
+	}
+}
+
+// Model represents a colour picker.
+type Model struct {
+	KeyMap KeyMap
+	Styles Styles
+
+	// Width is the width of the sliders.
+	Width int
+
+	original color.RGBA
+	color    color.RGBA
+
+	// Hue, saturation and lightness are kept separately from the colour so the
+	// hue isn't lost when the colour is grey.
+	hue        float64
+	saturation float64
+	lightness  float64
+
+	focus field
+	hex   textinput.Model
+}
+
+// New returns a new colour picker starting at the given colour.
+func New(c color.RGBA) Model {
+	hex := textinput.New()
+	hex.Prompt = ""
+	hex.CharLimit = 7
+	hex.Width = 7
+
+	m := Model{
+		KeyMap:   DefaultKeyMap(),
+		Styles:   DefaultStyles(),
+		Width:    16,
+		original: c,
+		hex:      hex,
+	}
+
+	m.setRGB(c)
+
+	return m
+}
+
+// Color is the colour that is currently picked.
+func (m Model) Color() color.RGBA {
+	return m.color
+}
+
+// Original is the colour the picker started with.
+func (m Model) Original() color.RGBA {
+	return m.original
+}
+
+// Value is the picked colour as a hex string, as used in a scheme palette.
+func (m Model) Value() string {
+	return builder.HexColor(m.color)
+}
+
+func (m *Model) setRGB(c color.RGBA) {
+	m.color = c
+
+	h, s, l := builder.RGBToHSL(c)
+	if s > 0 {
+		m.hue = h
+	}
+	m.saturation = s
+	m.lightness = l
+
+	if m.focus != hexField {
+		m.hex.SetValue(strings.TrimPrefix(m.Value(), "#"))
+	}
+}
+
+func (m *Model) setHSL(h, s, l float64) {
+	m.hue = math.Mod(h+360, 360)
+	m.saturation = clamp(s, 0, 1)
+	m.lightness = clamp(l, 0, 1)
+	m.color = builder.HSLToRGB(m.hue, m.saturation, m.lightness)
+	m.hex.SetValue(strings.TrimPrefix(m.Value(), "#"))
+}
+
+func (m *Model) setFocus(f field) tea.Cmd {
+	m.focus = f
+
+	if f == hexField {
+		m.hex.CursorEnd()
+		return m.hex.Focus()
+	}
+
+	m.hex.Blur()
+	m.hex.SetValue(strings.TrimPrefix(m.Value(), "#"))
+
+	return nil
+}
+
+// adjust moves the focused slider by steps, where a step is a degree of hue,
+// a percent of saturation or lightness, or one of an RGB channel.
+func (m *Model) adjust(steps float64) {
+	c := m.color
+
+	switch m.focus {
+	case hueField:
+		m.setHSL(m.hue+steps, m.saturation, m.lightness)
+	case saturationField:
+		m.setHSL(m.hue, m.saturation+steps/100, m.lightness)
+	case lightnessField:
+		m.setHSL(m.hue, m.saturation, m.lightness+steps/100)
+	case redField:
+		c.R = addByte(c.R, steps)
+		m.setRGB(c)
+	case greenField:
+		c.G = addByte(c.G, steps)
+		m.setRGB(c)
+	case blueField:
+		c.B = addByte(c.B, steps)
+		m.setRGB(c)
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.KeyMap.Next):
+			return m, m.setFocus((m.focus + 1) % fieldCount)
+		case key.Matches(msg, m.KeyMap.Prev):
+			return m, m.setFocus((m.focus + fieldCount - 1) % fieldCount)
+		}
+
+		if m.focus != hexField {
+			switch {
+			case key.Matches(msg, m.KeyMap.Increase):
+				m.adjust(1)
+			case key.Matches(msg, m.KeyMap.Decrease):
+				m.adjust(-1)
+			case key.Matches(msg, m.KeyMap.StepUp):
+				m.adjust(10)
+			case key.Matches(msg, m.KeyMap.StepDown):
+				m.adjust(-10)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.hex, cmd = m.hex.Update(msg)
+
+	if c, err := builder.ParseHexColor(m.hex.Value()); err == nil && c != m.color {
+		m.setRGB(c)
+	}
+
+	return m, cmd
+}
+
+// sliderColor is the colour the focused value would give at position t between 0 and 1.
+func (m Model) sliderColor(f field, t float64) color.RGBA {
+	c := m.color
+
+	switch f {
+	case hueField:
+		return builder.HSLToRGB(t*360, math.Max(m.saturation, 0.5), clamp(m.lightness, 0.25, 0.75))
+	case saturationField:
+		return builder.HSLToRGB(m.hue, t, m.lightness)
+	case lightnessField:
+		return builder.HSLToRGB(m.hue, m.saturation, t)
+	case redField:
+		c.R = uint8(math.Round(t * 255))
+	case greenField:
+		c.G = uint8(math.Round(t * 255))
+	case blueField:
+		c.B = uint8(math.Round(t * 255))
+	}
+
+	return c
+}
+
+func (m Model) fieldValue(f field) (float64, string) {
+	switch f {
+	case hueField:
+		return m.hue / 360, fmt.Sprintf("%3.0f", m.hue)
+	case saturationField:
+		return m.saturation, fmt.Sprintf("%3.0f", m.saturation*100)
+	case lightnessField:
+		return m.lightness, fmt.Sprintf("%3.0f", m.lightness*100)
+	case redField:
+		return float64(m.color.R) / 255, fmt.Sprintf("%3d", m.color.R)
+	case greenField:
+		return float64(m.color.G) / 255, fmt.Sprintf("%3d", m.color.G)
+	default:
+		return float64(m.color.B) / 255, fmt.Sprintf("%3d", m.color.B)
+	}
+}
+
+func (m Model) slider(f field) string {
+	value, text := m.fieldValue(f)
+	knob := int(math.Round(value * float64(m.Width-1)))
+
+	var b strings.Builder
+
+	for i := 0; i < m.Width; i++ {
+		t := float64(i) / float64(m.Width-1)
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(builder.HexColor(m.sliderColor(f, t))))
+
+		if i == knob {
+			b.WriteString(m.Styles.Knob.Render("●"))
+		} else {
+			b.WriteString(style.Render("━"))
+		}
+	}
+
+	return m.label(f) + " " + b.String() + " " + m.Styles.Value.Render(text)
+}
+
+func (m Model) label(f field) string {
+	if f == m.focus {
+		return m.Styles.FocusedLabel.Render(fieldLabels[f])
+	}
+
+	return m.Styles.Label.Render(fieldLabels[f])
+}
+
+func swatch(c color.RGBA, width int) string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(builder.HexColor(c))).Render(strings.Repeat("█", width))
+}
+
+func (m Model) View() string {
+	m.hex.TextStyle = m.Styles.Text
+	m.hex.Cursor.Style = m.Styles.Cursor
+
+	width := (m.Width + 6) / 2
+
+	lines := []string{
+		swatch(m.original, width) + "  " + swatch(m.color, width),
+		m.Styles.Value.Width(width+2).Render(builder.HexColor(m.original)) + m.Styles.Value.Render(m.Value()),
+		"",
+	}
+
+	for f := hueField; f < hexField; f++ {
+		lines = append(lines, m.slider(f))
+
+		if f == lightnessField {
+			lines = append(lines, "")
+		}
+	}
+
+	lines = append(lines, "", m.label(hexField)+" "+m.hex.View())
+
+	return strings.Join(lines, "\n")
+}
+
+func addByte(v uint8, steps float64) uint8 {
+	return uint8(clamp(float64(v)+steps, 0, 255))
+}
+
+func clamp(v, low, high float64) float64 {
+	return math.Min(math.Max(v, low), high)
+}
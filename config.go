@@ -2,6 +2,8 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"hash/fnv"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,21 +13,42 @@ import (
 )
 
 type Config struct {
-	DefaultShell  string `yaml:"DefaultShell"`
-	DefaultEditor string `yaml:"DefaultEditor"`
-	InsertStart   string `yaml:"InsertStart"`
-	InsertEnd     string `yaml:"InsertEnd"`
-	Paths         Paths  `yaml:"-"`
+	DefaultShell   string   `yaml:"DefaultShell"`
+	DefaultEditor  string   `yaml:"DefaultEditor"`
+	InsertStart    string   `yaml:"InsertStart"`
+	InsertEnd      string   `yaml:"InsertEnd"`
+	Schedule       Schedule `yaml:"Schedule"`
+	Sources        []Source `yaml:"Sources"`
+	FetchWithGit   bool     `yaml:"FetchWithGit"`
+	TerminalColors bool     `yaml:"TerminalColors"`
+	Paths          Paths    `yaml:"-"`
+}
+
+type Schedule struct {
+	Latitude  *float64        `yaml:"Latitude"`
+	Longitude *float64        `yaml:"Longitude"`
+	Events    []ScheduleEvent `yaml:"Events"`
+}
+
+type ScheduleEvent struct {
+	At    string `yaml:"At"`
+	Theme string `yaml:"Theme"`
 }
 
 type Paths struct {
-	Home          string
-	Apps          string
-	Templates     string
-	ActiveTheme   string
-	ThemeHooks    string
-	CustomSchemes string
-	BaseSchemes   string
+	Home              string
+	Apps              string
+	Templates         string
+	State             string
+	LegacyActiveTheme string
+	ThemeHooks        string
+	ThemeData         string
+	CustomSchemes     string
+	BaseSchemes       string
+	ThemeIndex        string
+	ScheduleState     string
+	ShellInit         string
+	Socket            string
 }
 
 var config = readConfig()
@@ -76,19 +99,50 @@ func readConfig() Config {
 		configYaml.InsertEnd = "END_PIN_HERE"
 	}
 
+	if len(configYaml.Sources) == 0 {
+		configYaml.Sources = defaultSources
+	}
+
+	err = ValidateSources(configYaml.Sources)
+	if err != nil {
+		panic(err)
+	}
+
 	configYaml.Paths = Paths{
-		Home:          filepath.Join(homePath, "pin"),
-		Apps:          filepath.Join(homePath, "pin", "apps.yaml"),
-		Templates:     filepath.Join(homePath, "pin", "templates"),
-		ActiveTheme:   filepath.Join(homePath, "pin", "activeTheme"),
-		ThemeHooks:    filepath.Join(homePath, "pin", "themeHooks.yaml"),
-		CustomSchemes: filepath.Join(homePath, "pin", "schemes"),
-		BaseSchemes:   filepath.Join(dataPath, "pin", "schemes"),
+		Home:              filepath.Join(homePath, "pin"),
+		Apps:              filepath.Join(homePath, "pin", "apps.yaml"),
+		Templates:         filepath.Join(homePath, "pin", "templates"),
+		State:             filepath.Join(homePath, "pin", "state.yaml"),
+		LegacyActiveTheme: filepath.Join(homePath, "pin", "activeTheme"),
+		ThemeHooks:        filepath.Join(homePath, "pin", "themeHooks.yaml"),
+		ThemeData:         filepath.Join(homePath, "pin", "themeData.yaml"),
+		CustomSchemes:     filepath.Join(homePath, "pin", "schemes"),
+		BaseSchemes:       filepath.Join(dataPath, "pin", "schemes"),
+		ThemeIndex:        filepath.Join(dataPath, "pin", "themeIndex.yaml"),
+		ScheduleState:     filepath.Join(dataPath, "pin", "schedule.yaml"),
+		ShellInit:         filepath.Join(dataPath, "pin", "shell"),
+		Socket:            socketPath(homePath, dataPath),
 	}
 
 	return configYaml
 }
 
+// socketPath gives each config and data home its own daemon, so a daemon
+// started with PIN_HOME or PIN_DATA never serves the themes of another home.
+func socketPath(homePath string, dataPath string) string {
+	if os.Getenv("PIN_HOME") == "" && os.Getenv("PIN_DATA") == "" {
+		return filepath.Join(xdg.RuntimeDir, "pin.sock")
+	}
+
+	homePath, _ = filepath.Abs(homePath)
+	dataPath, _ = filepath.Abs(dataPath)
+
+	hash := fnv.New32a()
+	hash.Write([]byte(homePath + "\x00" + dataPath))
+
+	return filepath.Join(xdg.RuntimeDir, fmt.Sprintf("pin-%08x.sock", hash.Sum32()))
+}
+
 var defaultConfigFile = `
 # Change the default shell and any required args
 DefaultShell: sh -c
@@ -101,4 +155,38 @@ InsertEnd: END_PIN_HERE
 
 # Change the default editor files will be opened in. By default pin will use $EDITOR env var or nano.
 # DefaultEditor: "nano"
+
+# Where schemes are fetched from. Each source needs a unique Name and one of
+# Git (with an optional branch or tag Ref), Dir (a local directory) or Tarball (a url or file).
+# Subdir limits the schemes listed to a directory within the source.
+# Git sources on GitHub and GitLab are downloaded as an archive so git isn't needed,
+# for other hosts set Archive to a tar.gz url of the repository or enable FetchWithGit.
+# Sources:
+#   - Name: tinted-theming
+#     Git: https://github.com/tinted-theming/schemes.git
+#     Subdir: base16
+#   - Name: work
+#     Git: https://git.example.com/team/schemes.git
+#     Ref: v2
+#     Archive: https://git.example.com/team/schemes/archive/v2.tar.gz
+#   - Name: local
+#     Dir: ~/schemes
+
+# Fetch Git sources with the git binary instead of downloading archives.
+# FetchWithGit: false
+
+# Recolour open terminals straight away when a theme is applied, using escape sequences.
+# TerminalColors: false
+
+# Themes to switch to automatically when running "pin schedule run".
+# At can be a time (15:04), sunrise or sunset with an optional offset (sunset-30m).
+# Latitude and Longitude are only needed for sunrise and sunset.
+# Schedule:
+#   Latitude: 51.5
+#   Longitude: -0.12
+#   Events:
+#     - At: sunrise
+#       Theme: rose-pine-dawn
+#     - At: sunset
+#       Theme: rose-pine
 `
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("PIN_HOME", "")
+	t.Setenv("PIN_DATA", "")
+
+	if got := filepath.Base(socketPath("/home/a/.config", "/home/a/.local/share")); got != "pin.sock" {
+		t.Errorf("socket without PIN_HOME = %s, want pin.sock", got)
+	}
+
+	t.Setenv("PIN_HOME", "/tmp/one")
+
+	one := socketPath("/tmp/one", "/home/a/.local/share")
+	two := socketPath("/tmp/two", "/home/a/.local/share")
+	otherData := socketPath("/tmp/one", "/tmp/data")
+
+	if one == two || one == otherData {
+		t.Errorf("homes share a socket: %s, %s, %s", one, two, otherData)
+	}
+
+	if again := socketPath("/tmp/one", "/home/a/.local/share"); again != one {
+		t.Errorf("socket path changed from %s to %s", one, again)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math/rand/v2"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// RandomTheme picks a random theme, avoiding the active theme when there is a choice.
+func RandomTheme(themes []list.Item) (Theme, bool) {
+	candidates := []Theme{}
+
+	for _, item := range themes {
+		theme := item.(Theme)
+		if !theme.Active {
+			candidates = append(candidates, theme)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if len(themes) == 0 {
+			return Theme{}, false
+		}
+		return themes[0].(Theme), true
+	}
+
+	return candidates[rand.IntN(len(candidates))], true
+}
+
+// StepTheme returns the theme offset from the active theme, wrapping around the list.
+// If the active theme is not in the list stepping starts from the beginning or end.
+func StepTheme(themes []list.Item, offset int) (Theme, bool) {
+	if len(themes) == 0 {
+		return Theme{}, false
+	}
+
+	current := -1
+
+	for i, item := range themes {
+		if item.(Theme).Active {
+			current = i
+			break
+		}
+	}
+
+	if current == -1 {
+		if offset > 0 {
+			return themes[0].(Theme), true
+		}
+		return themes[len(themes)-1].(Theme), true
+	}
+
+	next := ((current+offset)%len(themes) + len(themes)) % len(themes)
+
+	return themes[next].(Theme), true
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func cycleThemes(names []string, active string) []list.Item {
+	themes := []list.Item{}
+	for _, name := range names {
+		themes = append(themes, Theme{Name: name, Active: name == active})
+	}
+	return themes
+}
+
+func TestStepTheme(t *testing.T) {
+	names := []string{"a", "b", "c"}
+
+	tests := []struct {
+		active string
+		offset int
+		want   string
+	}{
+		{active: "a", offset: 1, want: "b"},
+		{active: "b", offset: -1, want: "a"},
+		{active: "c", offset: 1, want: "a"},
+		{active: "a", offset: -1, want: "c"},
+		{active: "a", offset: 5, want: "c"},
+		{active: "b", offset: -4, want: "a"},
+		{active: "", offset: 1, want: "a"},
+		{active: "", offset: -1, want: "c"},
+	}
+
+	for _, test := range tests {
+		theme, ok := StepTheme(cycleThemes(names, test.active), test.offset)
+		if !ok || theme.Name != test.want {
+			t.Errorf("StepTheme(active %q, %d) = %q, %v, want %q", test.active, test.offset, theme.Name, ok, test.want)
+		}
+	}
+
+	if _, ok := StepTheme(nil, 1); ok {
+		t.Error("StepTheme of no themes should not find a theme")
+	}
+}
+
+func TestRandomTheme(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		theme, ok := RandomTheme(cycleThemes([]string{"a", "b"}, "a"))
+		if !ok || theme.Name != "b" {
+			t.Fatalf("RandomTheme = %q, %v, want the only inactive theme", theme.Name, ok)
+		}
+	}
+
+	theme, ok := RandomTheme(cycleThemes([]string{"a"}, "a"))
+	if !ok || theme.Name != "a" {
+		t.Errorf("RandomTheme of only the active theme = %q, %v, want it anyway", theme.Name, ok)
+	}
+
+	if _, ok := RandomTheme(nil); ok {
+		t.Error("RandomTheme of no themes should not find a theme")
+	}
+}
@@ -0,0 +1,606 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClaraSmyth/pin/builder"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// The daemon keeps the theme list in memory and applies themes on request
+// over a unix socket. Requests and responses are single lines of JSON, e.g.
+//
+//	{"command": "apply", "theme": "nord"}
+//	{"ok": true, "theme": {"key": "bundled/base16/nord", ...}}
+//
+// Random and step apply a theme picked from the themes matching the filter,
+// so keybindings don't have to load every scheme themselves.
+//
+//	{"command": "step", "filter": "variant:dark", "offset": 1}
+//
+// Subscribing keeps the connection open and sends an event line whenever the
+// active theme changes or a theme is previewed.
+
+var daemonPollInterval = time.Second
+
+type DaemonRequest struct {
+	Command  string `json:"command"`
+	Theme    string `json:"theme,omitempty"`
+	Filter   string `json:"filter,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	Terminal bool   `json:"terminal,omitempty"`
+}
+
+type DaemonResponse struct {
+	OK     bool          `json:"ok"`
+	Error  string        `json:"error,omitempty"`
+	Event  string        `json:"event,omitempty"`
+	Theme  *DaemonTheme  `json:"theme,omitempty"`
+	Themes []DaemonTheme `json:"themes,omitempty"`
+
+	// Apps is the status of each app after applying, e.g. "unchanged"
+	Apps map[string]string `json:"apps,omitempty"`
+}
+
+// DaemonTheme is the theme as it is sent over the socket.
+type DaemonTheme struct {
+	Key             string            `json:"key"`
+	Name            string            `json:"name"`
+	DisplayName     string            `json:"displayName"`
+	Source          string            `json:"source"`
+	Variant         string            `json:"variant"`
+	VariantInferred bool              `json:"variantInferred,omitempty"`
+	Author          string            `json:"author,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	Favorite        bool              `json:"favorite,omitempty"`
+	Active          bool              `json:"active,omitempty"`
+	Palette         map[string]string `json:"palette,omitempty"`
+}
+
+func daemonTheme(theme Theme) DaemonTheme {
+	return DaemonTheme{
+		Key:             theme.Key().String(),
+		Name:            theme.Name,
+		DisplayName:     theme.DisplayName(),
+		Source:          theme.Source,
+		Variant:         theme.Variant,
+		VariantInferred: theme.VariantInferred,
+		Author:          theme.Author,
+		Tags:            theme.Tags,
+		Favorite:        theme.Favorite,
+		Active:          theme.Active,
+	}
+}
+
+func (t DaemonTheme) VariantLabel() string {
+	return Theme{Variant: t.Variant, VariantInferred: t.VariantInferred}.VariantLabel()
+}
+
+type daemon struct {
+	mu     sync.Mutex
+	themes []list.Item
+	active ThemeKey
+
+	// applyMu orders applies without blocking requests that only read the themes
+	applyMu sync.Mutex
+
+	// applied tells the watcher to reload and announce the theme just applied
+	applied chan struct{}
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan DaemonResponse]struct{}
+
+	log func(string)
+}
+
+// RunDaemon serves requests on the socket until stop is closed.
+func RunDaemon(stop <-chan struct{}, log func(string)) error {
+	if conn, err := net.Dial("unix", config.Paths.Socket); err == nil {
+		conn.Close()
+		return errors.New("The daemon is already running")
+	}
+
+	// A socket left behind by a daemon that didn't exit cleanly
+	_ = os.Remove(config.Paths.Socket)
+
+	listener, err := net.Listen("unix", config.Paths.Socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(config.Paths.Socket)
+
+	// Taken before loading the themes so changes made while loading are noticed
+	files := watchedFiles()
+
+	d := &daemon{
+		themes:      GetThemes(),
+		active:      ReadState().ActiveTheme,
+		applied:     make(chan struct{}, 1),
+		subscribers: make(map[chan DaemonResponse]struct{}),
+		log:         log,
+	}
+
+	// Closing quit stops the watcher however serving ends, and the watcher is
+	// waited for so nothing reads the config after returning
+	quit := make(chan struct{})
+	watching := sync.WaitGroup{}
+
+	defer watching.Wait()
+	defer close(quit)
+
+	go func() {
+		select {
+		case <-stop:
+		case <-quit:
+		}
+		listener.Close()
+	}()
+
+	watching.Add(1)
+	go func() {
+		defer watching.Done()
+		d.watch(quit, files)
+	}()
+
+	log("Listening on " + config.Paths.Socket)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		go d.serve(conn)
+	}
+}
+
+func (d *daemon) serve(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		request := DaemonRequest{}
+
+		err := json.Unmarshal(scanner.Bytes(), &request)
+		if err != nil {
+			_ = encoder.Encode(DaemonResponse{Error: "Invalid request: " + err.Error()})
+			continue
+		}
+
+		if request.Command == "subscribe" {
+			d.subscribe(conn, encoder)
+			return
+		}
+
+		err = encoder.Encode(d.handle(request))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (d *daemon) handle(request DaemonRequest) DaemonResponse {
+	switch request.Command {
+	case "apply", "random", "step":
+		theme, result, err := d.apply(request)
+		if err != nil {
+			return DaemonResponse{Error: err.Error()}
+		}
+
+		info := daemonTheme(theme)
+		info.Active = true
+		return DaemonResponse{OK: true, Theme: &info, Apps: result.apps()}
+
+	case "list":
+		d.mu.Lock()
+		themes := FilterThemes(d.themes, request.Filter)
+		d.mu.Unlock()
+
+		response := DaemonResponse{OK: true, Themes: []DaemonTheme{}}
+		for _, item := range themes {
+			response.Themes = append(response.Themes, daemonTheme(item.(Theme)))
+		}
+		return response
+
+	case "current":
+		d.mu.Lock()
+		query := d.active.String()
+		d.mu.Unlock()
+
+		return d.themeResponse(query, "")
+
+	case "preview":
+		response := d.themeResponse(request.Theme, "preview")
+		if !response.OK {
+			return response
+		}
+
+		if request.Terminal {
+			_, err := RecolorTerminals(builder.Scheme{Palette: response.Theme.Palette})
+			if err != nil {
+				return DaemonResponse{Error: err.Error()}
+			}
+		}
+
+		d.broadcast(response)
+		response.Event = ""
+		return response
+
+	case "reload":
+		d.reload()
+		return DaemonResponse{OK: true}
+
+	default:
+		return DaemonResponse{Error: fmt.Sprintf("Unknown command %q", request.Command)}
+	}
+}
+
+// themeResponse finds the theme and includes its palette.
+func (d *daemon) themeResponse(query string, event string) DaemonResponse {
+	theme, found := d.find(query)
+	if !found {
+		return DaemonResponse{Error: fmt.Sprintf("Theme %q not found", query)}
+	}
+
+	scheme, err := ReadThemeScheme(theme)
+	if err != nil {
+		return DaemonResponse{Error: err.Error()}
+	}
+
+	info := daemonTheme(theme)
+	info.Palette = scheme.Palette
+
+	return DaemonResponse{OK: true, Event: event, Theme: &info}
+}
+
+// find looks the theme up in the cached list, reloading the list once if it
+// isn't found in case the theme was added since.
+func (d *daemon) find(query string) (Theme, bool) {
+	d.mu.Lock()
+	theme, found := findThemeIn(d.themes, query)
+	d.mu.Unlock()
+
+	if found {
+		return theme, true
+	}
+
+	d.reload()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return findThemeIn(d.themes, query)
+}
+
+func (d *daemon) reload() {
+	themes := GetThemes()
+
+	d.mu.Lock()
+	d.themes = themes
+	d.mu.Unlock()
+}
+
+// resolve finds the theme to apply. Random and step pick from the cached
+// themes matching the filter, relative to the active theme.
+func (d *daemon) resolve(request DaemonRequest) (Theme, error) {
+	if request.Command == "apply" {
+		theme, found := d.find(request.Theme)
+		if !found {
+			return Theme{}, fmt.Errorf("Theme %q not found", request.Theme)
+		}
+		return theme, nil
+	}
+
+	d.mu.Lock()
+	themes := FilterThemes(d.themes, request.Filter)
+	active := d.active
+	d.mu.Unlock()
+
+	// The cached list is reloaded in the background after an apply so it may
+	// not have caught up with the active theme yet
+	for i, item := range themes {
+		theme := item.(Theme)
+		theme.Active = theme.Key() == active
+		themes[i] = theme
+	}
+
+	var theme Theme
+	var found bool
+
+	if request.Command == "random" {
+		theme, found = RandomTheme(themes)
+	} else {
+		theme, found = StepTheme(themes, request.Offset)
+	}
+
+	if !found {
+		return Theme{}, errors.New("No themes match the filter")
+	}
+
+	return theme, nil
+}
+
+// apply resolves and applies the theme of the request. Resolving happens under
+// the apply lock so stepping twice quickly steps from the theme applied first.
+func (d *daemon) apply(request DaemonRequest) (Theme, ApplyResult, error) {
+	d.applyMu.Lock()
+	defer d.applyMu.Unlock()
+
+	theme, err := d.resolve(request)
+	if err != nil {
+		return Theme{}, nil, err
+	}
+
+	if request.Terminal && !config.TerminalColors {
+		scheme, err := ReadThemeScheme(theme)
+		if err == nil {
+			_, _ = RecolorTerminals(scheme)
+		}
+	}
+
+	result, err := applyTheme(theme)
+	if err != nil {
+		return Theme{}, nil, errors.New("There was an error applying this theme!")
+	}
+
+	d.mu.Lock()
+	d.active = theme.Key()
+	d.mu.Unlock()
+
+	d.log(applyLogMessage("Applied "+theme.Key().String(), result))
+
+	// The active and recent themes have changed
+	select {
+	case d.applied <- struct{}{}:
+	default:
+	}
+
+	return theme, result, nil
+}
+
+// watch notices changes made without the daemon, e.g. themes applied from the
+// TUI or fetched, imported, tagged and favorited by other commands.
+func (d *daemon) watch(stop <-chan struct{}, files daemonFiles) {
+	ticker := time.NewTicker(daemonPollInterval)
+	defer ticker.Stop()
+
+	for {
+		reload := false
+		changed := false
+
+		select {
+		case <-stop:
+			return
+		case <-d.applied:
+			reload = true
+			changed = true
+		case <-ticker.C:
+		}
+
+		current := watchedFiles()
+
+		if current.themes != files.themes {
+			reload = true
+		}
+
+		if !current.state.Equal(files.state) {
+			active := ReadState().ActiveTheme
+
+			d.mu.Lock()
+			changed = changed || active != d.active
+			d.active = active
+			d.mu.Unlock()
+		}
+
+		files = current
+
+		if reload || changed {
+			d.reload()
+		}
+
+		if changed {
+			d.mu.Lock()
+			active := d.active
+			d.mu.Unlock()
+
+			d.broadcast(d.themeResponse(active.String(), "apply"))
+		}
+	}
+}
+
+// daemonFiles is what the daemon watches for changes made without it.
+type daemonFiles struct {
+	state  time.Time
+	themes uint64
+}
+
+func watchedFiles() daemonFiles {
+	files := daemonFiles{themes: themeFilesVersion()}
+
+	if info, err := os.Stat(config.Paths.State); err == nil {
+		files.state = info.ModTime()
+	}
+
+	return files
+}
+
+// themeFilesVersion changes whenever a file the theme list is built from is
+// added, removed or modified.
+func themeFilesVersion() uint64 {
+	hash := fnv.New64a()
+
+	add := func(path string, info fs.FileInfo) {
+		fmt.Fprintf(hash, "%s %d %d\n", path, info.ModTime().UnixNano(), info.Size())
+	}
+
+	for _, path := range []string{config.Paths.ThemeData, config.Paths.ThemeHooks, config.Paths.ThemeIndex} {
+		if info, err := os.Stat(path); err == nil {
+			add(path, info)
+		}
+	}
+
+	roots := []string{config.Paths.CustomSchemes}
+	for _, source := range config.Sources {
+		roots = append(roots, source.SchemesPath())
+	}
+
+	for _, root := range roots {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			if !d.IsDir() && !strings.HasSuffix(d.Name(), ".yaml") {
+				return nil
+			}
+
+			if info, err := d.Info(); err == nil {
+				add(path, info)
+			}
+
+			return nil
+		})
+	}
+
+	return hash.Sum64()
+}
+
+func (d *daemon) subscribe(conn net.Conn, encoder *json.Encoder) {
+	events := make(chan DaemonResponse, 8)
+
+	d.subscribersMu.Lock()
+	d.subscribers[events] = struct{}{}
+	d.subscribersMu.Unlock()
+
+	defer func() {
+		d.subscribersMu.Lock()
+		delete(d.subscribers, events)
+		d.subscribersMu.Unlock()
+	}()
+
+	// Reading fails once the subscriber disconnects
+	closed := make(chan struct{})
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := conn.Read(buf); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+
+	if encoder.Encode(DaemonResponse{OK: true}) != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event := <-events:
+			if encoder.Encode(event) != nil {
+				return
+			}
+		}
+	}
+}
+
+// broadcast sends the event to every subscriber, skipping subscribers that
+// aren't keeping up.
+func (d *daemon) broadcast(event DaemonResponse) {
+	if !event.OK {
+		return
+	}
+
+	d.subscribersMu.Lock()
+	defer d.subscribersMu.Unlock()
+
+	for events := range d.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+var errDaemonNotRunning = errors.New("The daemon is not running")
+
+// SendDaemonRequest sends a request to the daemon and returns the error in
+// the response if it failed.
+func SendDaemonRequest(request DaemonRequest) (DaemonResponse, error) {
+	conn, err := net.Dial("unix", config.Paths.Socket)
+	if err != nil {
+		return DaemonResponse{}, errDaemonNotRunning
+	}
+	defer conn.Close()
+
+	err = json.NewEncoder(conn).Encode(request)
+	if err != nil {
+		return DaemonResponse{}, err
+	}
+
+	response := DaemonResponse{}
+
+	err = json.NewDecoder(conn).Decode(&response)
+	if err != nil {
+		return DaemonResponse{}, err
+	}
+
+	if !response.OK {
+		return response, errors.New(response.Error)
+	}
+
+	return response, nil
+}
+
+// DaemonSubscribe calls handle with each event until the connection closes.
+func DaemonSubscribe(handle func(DaemonResponse)) error {
+	conn, err := net.Dial("unix", config.Paths.Socket)
+	if err != nil {
+		return errDaemonNotRunning
+	}
+	defer conn.Close()
+
+	err = json.NewEncoder(conn).Encode(DaemonRequest{Command: "subscribe"})
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(conn)
+
+	for {
+		event := DaemonResponse{}
+
+		err = decoder.Decode(&event)
+		if err != nil {
+			return err
+		}
+
+		if event.Event != "" {
+			handle(event)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// startDaemon runs the daemon on a test config until the test ends.
+func startDaemon(t *testing.T) string {
+	t.Helper()
+
+	root := useTestConfig(t)
+	config.TerminalColors = false
+
+	interval := daemonPollInterval
+	daemonPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { daemonPollInterval = interval })
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- RunDaemon(stop, func(string) {})
+	}()
+
+	t.Cleanup(func() {
+		close(stop)
+		if err := <-done; err != nil {
+			t.Errorf("RunDaemon returned %v", err)
+		}
+	})
+
+	for i := 0; i < 200; i++ {
+		if conn, err := net.Dial("unix", config.Paths.Socket); err == nil {
+			conn.Close()
+			return root
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("the daemon didn't start listening")
+	return root
+}
+
+func mustSend(t *testing.T, request DaemonRequest) DaemonResponse {
+	t.Helper()
+
+	response, err := SendDaemonRequest(request)
+	if err != nil {
+		t.Fatalf("%s: %v", request.Command, err)
+	}
+
+	return response
+}
+
+func themeKeys(themes []DaemonTheme) []string {
+	keys := []string{}
+	for _, theme := range themes {
+		keys = append(keys, theme.Key)
+	}
+	return keys
+}
+
+// eventually retries check until it passes, as the daemon polls for changes.
+func eventually(t *testing.T, message string, check func() bool) {
+	t.Helper()
+
+	for i := 0; i < 200; i++ {
+		if check() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Error(message)
+}
+
+func TestDaemonRoundTrip(t *testing.T) {
+	startDaemon(t)
+
+	light := mustSend(t, DaemonRequest{Command: "list", Filter: "variant:light"})
+	if len(light.Themes) < 2 {
+		t.Fatalf("list variant:light = %v, want at least two themes", themeKeys(light.Themes))
+	}
+
+	for _, theme := range light.Themes {
+		if theme.Variant != "light" {
+			t.Errorf("list variant:light includes %s with variant %s", theme.Key, theme.Variant)
+		}
+	}
+
+	first, second := light.Themes[0].Key, light.Themes[1].Key
+
+	applied := mustSend(t, DaemonRequest{Command: "apply", Theme: first})
+	if applied.Theme == nil || applied.Theme.Key != first || !applied.Theme.Active {
+		t.Fatalf("apply %s = %+v", first, applied.Theme)
+	}
+
+	if state := ReadState(); state.ActiveTheme.String() != first {
+		t.Errorf("active theme in state = %s, want %s", state.ActiveTheme, first)
+	}
+
+	current := mustSend(t, DaemonRequest{Command: "current"})
+	if current.Theme == nil || current.Theme.Key != first || current.Theme.Palette["base00"] == "" {
+		t.Errorf("current = %+v, want %s with its palette", current.Theme, first)
+	}
+
+	next := mustSend(t, DaemonRequest{Command: "step", Filter: "variant:light", Offset: 1})
+	if next.Theme == nil || next.Theme.Key != second {
+		t.Errorf("step 1 = %+v, want %s", next.Theme, second)
+	}
+
+	prev := mustSend(t, DaemonRequest{Command: "step", Filter: "variant:light", Offset: -1})
+	if prev.Theme == nil || prev.Theme.Key != first {
+		t.Errorf("step -1 = %+v, want %s", prev.Theme, first)
+	}
+
+	random := mustSend(t, DaemonRequest{Command: "random", Filter: "variant:light"})
+	if random.Theme == nil || !slices.Contains(themeKeys(light.Themes), random.Theme.Key) {
+		t.Errorf("random variant:light = %+v", random.Theme)
+	}
+
+	errorRequests := []DaemonRequest{
+		{Command: "apply", Theme: "no-such-theme"},
+		{Command: "random", Filter: "variant:none"},
+		{Command: "preview", Theme: "no-such-theme"},
+		{Command: "explode"},
+	}
+
+	for _, request := range errorRequests {
+		if response, err := SendDaemonRequest(request); err == nil || response.Error == "" {
+			t.Errorf("%+v should fail, got %+v", request, response)
+		}
+	}
+}
+
+func TestDaemonConnection(t *testing.T) {
+	startDaemon(t)
+
+	conn, err := net.Dial("unix", config.Paths.Socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Several requests are answered on one connection, one line each
+	lines := bufio.NewScanner(conn)
+	for _, line := range []string{"not json", `{"command": "current"}`, `{"command": "reload"}`} {
+		if _, err := conn.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+
+		if !lines.Scan() {
+			t.Fatalf("no response to %s", line)
+		}
+
+		response := DaemonResponse{}
+		if err := json.Unmarshal(lines.Bytes(), &response); err != nil {
+			t.Fatalf("response to %s is not JSON: %s", line, lines.Bytes())
+		}
+
+		if line == `{"command": "reload"}` && !response.OK {
+			t.Errorf("reload = %+v", response)
+		}
+
+		if line == "not json" && response.OK {
+			t.Errorf("invalid request = %+v, want an error", response)
+		}
+	}
+}
+
+func TestDaemonSubscribe(t *testing.T) {
+	startDaemon(t)
+
+	conn, err := net.Dial("unix", config.Paths.Socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(DaemonRequest{Command: "subscribe"}); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := json.NewDecoder(conn)
+
+	subscribed := DaemonResponse{}
+	if err := decoder.Decode(&subscribed); err != nil || !subscribed.OK {
+		t.Fatalf("subscribe = %+v, %v", subscribed, err)
+	}
+
+	mustSend(t, DaemonRequest{Command: "apply", Theme: "bundled/base16/nord"})
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	event := DaemonResponse{}
+	if err := decoder.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Event != "apply" || event.Theme == nil || event.Theme.Key != "bundled/base16/nord" {
+		t.Errorf("event = %+v, want nord applied", event)
+	}
+}
+
+func TestDaemonReload(t *testing.T) {
+	startDaemon(t)
+
+	listed := func(filter string, key string) func() bool {
+		return func() bool {
+			response, err := SendDaemonRequest(DaemonRequest{Command: "list", Filter: filter})
+			return err == nil && slices.Contains(themeKeys(response.Themes), key)
+		}
+	}
+
+	// A scheme added by another command, e.g. pin scheme import
+	nord, err := bundledSchemes.ReadFile("bundled/base16/nord.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(config.Paths.CustomSchemes, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(config.Paths.CustomSchemes, "mine.yaml"), nord, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, "the imported scheme is not listed", listed("mine", "custom/mine"))
+
+	// Favorites and tags changed in the TUI
+	WriteThemeData(ThemeData{Favorites: []string{"custom/mine"}, Tags: map[string][]string{"custom/mine": {"work"}}})
+
+	eventually(t, "the new favorite is not listed", listed("is:favorite", "custom/mine"))
+	eventually(t, "the new tag is not listed", listed("tag:work", "custom/mine"))
+
+	// A theme applied without the daemon
+	if err := WriteState(State{ActiveTheme: ThemeKey{Source: customSource, Slug: "mine"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, "the daemon didn't notice the applied theme", func() bool {
+		response, err := SendDaemonRequest(DaemonRequest{Command: "current"})
+		return err == nil && response.Theme.Key == "custom/mine"
+	})
+}
+
+func TestDaemonNotRunning(t *testing.T) {
+	useTestConfig(t)
+	config.TerminalColors = false
+
+	if _, err := SendDaemonRequest(DaemonRequest{Command: "current"}); !errors.Is(err, errDaemonNotRunning) {
+		t.Fatalf("SendDaemonRequest without a daemon = %v, want %v", err, errDaemonNotRunning)
+	}
+
+	// Commands apply the theme themselves
+	if err := runCli([]string{"nord"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := ReadState(); state.ActiveTheme.String() != "bundled/base16/nord" {
+		t.Errorf("active theme = %s, want bundled/base16/nord", state.ActiveTheme)
+	}
+
+	if err := runCli([]string{"next", "--variant", "dark"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := ReadState(); state.ActiveTheme.String() == "bundled/base16/nord" {
+		t.Error("pin next without a daemon didn't change the active theme")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ClaraSmyth/pin/builder"
+)
+
+// Built in templates for exporting a scheme without configuring an app.
+//
+//go:embed exports/*.mustache
+var exportTemplates embed.FS
+
+var exportFormats = []string{"css", "json", "xresources", "sh", "lua"}
+
+// ExportScheme renders the scheme with the built in template for the format.
+func ExportScheme(scheme builder.Scheme, format string) (string, error) {
+	if !slices.Contains(exportFormats, format) {
+		return "", fmt.Errorf("Unknown export format %q, expected one of %s", format, strings.Join(exportFormats, ", "))
+	}
+
+	template, err := exportTemplates.ReadFile("exports/" + format + ".mustache")
+	if err != nil {
+		return "", err
+	}
+
+	// The name, author and description are written into strings and comments
+	// so they are escaped for the format first
+	escape := exportEscape(format)
+	scheme.Name = escape(scheme.Name)
+	scheme.Author = escape(scheme.Author)
+	scheme.Description = escape(scheme.Description)
+
+	return builder.BuildTemplate(scheme, template)
+}
+
+func exportEscape(format string) func(string) string {
+	switch format {
+	case "json":
+		return func(s string) string {
+			var b strings.Builder
+			encoder := json.NewEncoder(&b)
+			encoder.SetEscapeHTML(false)
+			encoder.Encode(s)
+			quoted := strings.TrimSpace(b.String())
+			return quoted[1 : len(quoted)-1]
+		}
+	case "lua":
+		return func(s string) string {
+			quoted := strconv.Quote(s)
+			return quoted[1 : len(quoted)-1]
+		}
+	case "css":
+		return func(s string) string {
+			return strings.ReplaceAll(singleLine(s), "*/", "* /")
+		}
+	default:
+		return singleLine
+	}
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
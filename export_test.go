@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ClaraSmyth/pin/builder"
+)
+
+func exportTestScheme(name string, author string) builder.Scheme {
+	scheme := builder.Scheme{System: "base16", Name: name, Author: author, Variant: "dark", Palette: map[string]string{}}
+	for i, key := range builder.Base16Keys {
+		scheme.Palette[key] = []string{"#000000", "#ffffff"}[i%2]
+	}
+	return scheme
+}
+
+func TestExportSchemeJSON(t *testing.T) {
+	names := []string{
+		"Plain",
+		`Say "hi"`,
+		`Back\slash`,
+		"Two\nlines",
+		"<Tags> & ampersands",
+		"Ünïcödé",
+	}
+
+	for _, name := range names {
+		out, err := ExportScheme(exportTestScheme(name, name), "json")
+		if err != nil {
+			t.Errorf("%q: ExportScheme returned %v", name, err)
+			continue
+		}
+
+		var exported struct {
+			Name    string            `json:"name"`
+			Author  string            `json:"author"`
+			Palette map[string]string `json:"palette"`
+		}
+
+		if err := json.Unmarshal([]byte(out), &exported); err != nil {
+			t.Errorf("%q: exported invalid JSON: %v\n%s", name, err, out)
+			continue
+		}
+
+		if exported.Name != name || exported.Author != name {
+			t.Errorf("%q: exported name %q author %q", name, exported.Name, exported.Author)
+		}
+
+		if exported.Palette["base01"] != "#ffffff" {
+			t.Errorf("%q: exported base01 = %q, want #ffffff", name, exported.Palette["base01"])
+		}
+	}
+}
+
+func TestExportSchemeEscaping(t *testing.T) {
+	tests := []struct {
+		format  string
+		name    string
+		want    string
+		notWant string
+	}{
+		{format: "lua", name: `Say "hi"`, want: `name = "Say \"hi\""`},
+		{format: "lua", name: "Two\nlines", want: `name = "Two\nlines"`, notWant: "Two\nlines"},
+		{format: "css", name: "Sneaky */ body {}", want: "/* Sneaky * / body {} by", notWant: "*/ body"},
+		{format: "css", name: "Two\nlines", want: "/* Two lines by"},
+		{format: "sh", name: "Two\nlines\necho oops", want: "# Two lines echo oops by", notWant: "\necho oops"},
+		{format: "xresources", name: "Two\n\tlines", want: "! Two lines by"},
+	}
+
+	for _, test := range tests {
+		out, err := ExportScheme(exportTestScheme(test.name, "Author"), test.format)
+		if err != nil {
+			t.Errorf("%s %q: ExportScheme returned %v", test.format, test.name, err)
+			continue
+		}
+
+		if !strings.Contains(out, test.want) {
+			t.Errorf("%s %q: output does not contain %q\n%s", test.format, test.name, test.want, out)
+		}
+
+		if test.notWant != "" && strings.Contains(out, test.notWant) {
+			t.Errorf("%s %q: output contains %q\n%s", test.format, test.name, test.notWant, out)
+		}
+	}
+}
+
+func TestExportSchemeFormats(t *testing.T) {
+	for _, format := range exportFormats {
+		out, err := ExportScheme(exportTestScheme("Test", "Author"), format)
+		if err != nil {
+			t.Errorf("%s: ExportScheme returned %v", format, err)
+			continue
+		}
+
+		if !strings.Contains(out, "ffffff") || strings.Contains(out, "{{") {
+			t.Errorf("%s: template was not rendered\n%s", format, out)
+		}
+	}
+
+	if _, err := ExportScheme(exportTestScheme("Test", "Author"), "yaml"); err == nil {
+		t.Error("ExportScheme with an unknown format should fail")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// Theme queries are space separated terms. Terms in the form "field:value"
+// match against theme metadata, everything else is matched against the name.
+//
+//	variant:light author:chris tag:work is:favorite gruvbox
+
+var themeQueryFields = map[string]func(Theme) string{
+	"name":    func(t Theme) string { return t.Name },
+	"variant": func(t Theme) string { return t.Variant },
+	"author":  func(t Theme) string { return t.Author },
+	"system":  func(t Theme) string { return t.System },
+	"slug":    func(t Theme) string { return t.Slug },
+	"source":  func(t Theme) string { return t.Source },
+	"is":      themeStatus,
+}
+
+// List fields only match when one of the values equals the term, so tag:work
+// doesn't match a theme tagged "network".
+var themeQueryLists = map[string]func(Theme) []string{
+	"tag": func(t Theme) []string { return t.Tags },
+}
+
+func isThemeQueryField(field string) bool {
+	_, value := themeQueryFields[field]
+	_, list := themeQueryLists[field]
+	return value || list
+}
+
+func themeStatus(t Theme) string {
+	status := []string{}
+
+	if t.Favorite {
+		status = append(status, "favorite")
+	}
+
+	if t.Recent > 0 {
+		status = append(status, "recent")
+	}
+
+	if t.Active {
+		status = append(status, "active")
+	}
+
+	if len(t.LowContrast) > 0 {
+		status = append(status, "low-contrast")
+	}
+
+	return strings.Join(status, " ")
+}
+
+type ThemeQuery struct {
+	Text   string
+	Fields map[string][]string
+}
+
+func ParseThemeQuery(query string) ThemeQuery {
+	q := ThemeQuery{Fields: make(map[string][]string)}
+	text := []string{}
+
+	for _, term := range strings.Fields(query) {
+		field, value, found := strings.Cut(term, ":")
+		field = strings.ToLower(field)
+
+		if found && isThemeQueryField(field) && value != "" {
+			q.Fields[field] = append(q.Fields[field], strings.ToLower(value))
+			continue
+		}
+
+		text = append(text, term)
+	}
+
+	q.Text = strings.Join(text, " ")
+
+	return q
+}
+
+// MatchFields reports whether the theme satisfies every field term of the query.
+func (q ThemeQuery) MatchFields(theme Theme) bool {
+	for field, values := range q.Fields {
+		if list, ok := themeQueryLists[field]; ok {
+			for _, value := range values {
+				if !slices.ContainsFunc(list(theme), func(v string) bool { return strings.EqualFold(v, value) }) {
+					return false
+				}
+			}
+			continue
+		}
+
+		themeValue := strings.ToLower(themeQueryFields[field](theme))
+
+		for _, value := range values {
+			if !strings.Contains(themeValue, value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (q ThemeQuery) Match(theme Theme) bool {
+	name := strings.ToLower(theme.Name)
+
+	for _, term := range strings.Fields(strings.ToLower(q.Text)) {
+		if !strings.Contains(name, term) {
+			return false
+		}
+	}
+
+	return q.MatchFields(theme)
+}
+
+func FilterThemes(themes []list.Item, query string) []list.Item {
+	q := ParseThemeQuery(query)
+	filtered := []list.Item{}
+
+	for _, item := range themes {
+		if q.Match(item.(Theme)) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	return filtered
+}
+
+// ThemeFilter fuzzy matches the free text of a query against the theme names
+// like the default list filter, then drops themes that fail the field terms.
+func ThemeFilter(l *list.Model) list.FilterFunc {
+	return func(term string, targets []string) []list.Rank {
+		items := l.Items()
+		if len(items) != len(targets) {
+			return list.DefaultFilter(term, targets)
+		}
+
+		q := ParseThemeQuery(term)
+
+		var ranks []list.Rank
+
+		if q.Text == "" {
+			for i := range targets {
+				ranks = append(ranks, list.Rank{Index: i})
+			}
+		} else {
+			ranks = list.DefaultFilter(q.Text, targets)
+		}
+
+		filtered := []list.Rank{}
+
+		for _, rank := range ranks {
+			if q.MatchFields(items[rank.Index].(Theme)) {
+				filtered = append(filtered, rank)
+			}
+		}
+
+		return filtered
+	}
+}
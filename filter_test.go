@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestParseThemeQuery(t *testing.T) {
+	tests := []struct {
+		query  string
+		text   string
+		fields map[string][]string
+	}{
+		{query: "", text: "", fields: map[string][]string{}},
+		{query: "gruvbox dark", text: "gruvbox dark", fields: map[string][]string{}},
+		{query: "variant:Light gruvbox", text: "gruvbox", fields: map[string][]string{"variant": {"light"}}},
+		{query: "tag:work tag:blue", text: "", fields: map[string][]string{"tag": {"work", "blue"}}},
+		{query: "Author:chris", text: "", fields: map[string][]string{"author": {"chris"}}},
+		{query: "colour:red", text: "colour:red", fields: map[string][]string{}},
+		{query: "variant:", text: "variant:", fields: map[string][]string{}},
+		{query: "  is:favorite   nord ", text: "nord", fields: map[string][]string{"is": {"favorite"}}},
+	}
+
+	for _, test := range tests {
+		q := ParseThemeQuery(test.query)
+
+		if q.Text != test.text {
+			t.Errorf("ParseThemeQuery(%q).Text = %q, want %q", test.query, q.Text, test.text)
+		}
+
+		if !reflect.DeepEqual(q.Fields, test.fields) {
+			t.Errorf("ParseThemeQuery(%q).Fields = %v, want %v", test.query, q.Fields, test.fields)
+		}
+	}
+}
+
+func TestFilterThemes(t *testing.T) {
+	themes := []list.Item{
+		Theme{Name: "Gruvbox Dark", Variant: "dark", Author: "Dawid Kurek", Source: "tinted-theming", Tags: []string{"work"}},
+		Theme{Name: "Gruvbox Light", Variant: "light", Author: "Dawid Kurek", Source: "tinted-theming", Favorite: true},
+		Theme{Name: "Nord", Variant: "dark", Author: "arcticicestudio", Source: "bundled", Active: true},
+		Theme{Name: "Solarized Light", Variant: "light", Author: "Ethan Schoonover", Source: "custom", Recent: 1, LowContrast: []string{"comments"}},
+		Theme{Name: "Tokyo Night", Variant: "dark", Source: "tinted-theming", Tags: []string{"network", "Homework", "blue"}},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: []string{"Gruvbox Dark", "Gruvbox Light", "Nord", "Solarized Light", "Tokyo Night"}},
+		{query: "gruvbox", want: []string{"Gruvbox Dark", "Gruvbox Light"}},
+		{query: "GRUV light", want: []string{"Gruvbox Light"}},
+		{query: "variant:dark", want: []string{"Gruvbox Dark", "Nord", "Tokyo Night"}},
+		{query: "variant:light author:ethan", want: []string{"Solarized Light"}},
+		{query: "source:bundled", want: []string{"Nord"}},
+		{query: "tag:work", want: []string{"Gruvbox Dark"}},
+		{query: "tag:WORK", want: []string{"Gruvbox Dark"}},
+		{query: "tag:homework", want: []string{"Tokyo Night"}},
+		{query: "tag:net", want: []string{}},
+		{query: "tag:network tag:blue", want: []string{"Tokyo Night"}},
+		{query: "tag:work tag:blue", want: []string{}},
+		{query: "is:favorite", want: []string{"Gruvbox Light"}},
+		{query: "is:recent", want: []string{"Solarized Light"}},
+		{query: "is:active", want: []string{"Nord"}},
+		{query: "is:low-contrast", want: []string{"Solarized Light"}},
+		{query: "nord variant:light", want: []string{}},
+	}
+
+	for _, test := range tests {
+		got := []string{}
+		for _, item := range FilterThemes(themes, test.query) {
+			got = append(got, item.(Theme).Name)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("FilterThemes(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
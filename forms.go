@@ -23,6 +23,7 @@ var (
 	formEdit       bool
 	formName       string
 	formHook       string
+	formTags       string
 	formRewrite    bool
 	formFilepicker bool
 	formApply      bool
@@ -168,6 +169,47 @@ func deleteForm(theme *huh.Theme) *huh.Form {
 	).WithShowHelp(false).WithWidth(25).WithTheme(theme)
 }
 
+func tagsForm(theme *huh.Theme) *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Key("tags").
+				Title("Tags").
+				Description("Comma separated").
+				Validate(validateTags).
+				Value(&formTags),
+
+			huh.NewConfirm().
+				Key("apply").
+				Title("Confirm?").
+				Value(&formApply),
+		),
+	).WithShowHelp(false).WithWidth(25).WithTheme(theme)
+}
+
+func parseTags(input string) []string {
+	tags := []string{}
+
+	for _, tag := range strings.Split(input, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// validateTags rejects tags with spaces as queries are split on spaces.
+func validateTags(input string) error {
+	for _, tag := range parseTags(input) {
+		if strings.ContainsFunc(tag, unicode.IsSpace) {
+			return errors.New("No spaces in tags!")
+		}
+	}
+	return nil
+}
+
 func validateFilename(filename string) bool {
 	for _, v := range filename {
 		if !unicode.IsLetter(v) && !unicode.IsDigit(v) && string(v) != "-" {
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+		valid bool
+	}{
+		{input: "", want: []string{}, valid: true},
+		{input: "work", want: []string{"work"}, valid: true},
+		{input: " work , blue,,work ", want: []string{"work", "blue"}, valid: true},
+		{input: "work, dark blue", want: []string{"work", "dark blue"}, valid: false},
+		{input: "tab\tseparated", want: []string{"tab\tseparated"}, valid: false},
+	}
+
+	for _, test := range tests {
+		if got := parseTags(test.input); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseTags(%q) = %q, want %q", test.input, got, test.want)
+		}
+
+		if err := validateTags(test.input); (err == nil) != test.valid {
+			t.Errorf("validateTags(%q) = %v, want valid %v", test.input, err, test.valid)
+		}
+	}
+}
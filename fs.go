@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"slices"
 	"strconv"
@@ -383,49 +383,182 @@ func CopyTemplate(app App, template Template) tea.Cmd {
 }
 
 func GetThemes() []list.Item {
-	activeThemePath, _ := os.ReadFile(config.Paths.ActiveTheme)
+	state := ReadState()
 
-	themeList := []list.Item{}
+	themes := []Theme{}
+
+	index := ReadThemeIndex()
+	seen := make(map[string]bool)
+	names := make(map[string]int)
+	fetched := make(map[string]ThemeKey)
+	hidden := make(map[ThemeKey]ThemeKey)
+
+	walkThemes := func(root string, source string, walk func(string, fs.WalkDirFunc) error) {
+		_ = walk(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			if d.IsDir() || !strings.HasSuffix(d.Name(), ".yaml") {
+				return nil
+			}
+
+			name := strings.TrimSuffix(d.Name(), ".yaml")
+			slug := themeSlug(root, path)
+
+			// Bundled themes are only a fallback for themes that haven't been fetched
+			if source == bundledSource && !fetched[name].IsZero() {
+				hidden[ThemeKey{Source: source, Slug: slug}] = fetched[name]
+				return nil
+			}
+
+			entry, err := index.Lookup(path, d)
+			if err != nil {
+				return nil
+			}
+			seen[path] = true
+			names[name]++
+
+			if source != bundledSource && fetched[name].IsZero() {
+				fetched[name] = ThemeKey{Source: source, Slug: slug}
+			}
+
+			themes = append(themes, Theme{
+				Name:            name,
+				Path:            path,
+				Variant:         entry.Variant,
+				Author:          entry.Author,
+				System:          entry.System,
+				LowContrast:     entry.LowContrast,
+				VariantInferred: entry.VariantInferred,
+				Slug:            slug,
+				Source:          source,
+			})
+
+			return nil
+		})
+	}
+
+	walkThemes(config.Paths.CustomSchemes, customSource, filepath.WalkDir)
+
+	for _, source := range config.Sources {
+		walkThemes(source.SchemesPath(), source.Name, filepath.WalkDir)
+	}
+
+	walkThemes(bundledSource, bundledSource, func(root string, fn fs.WalkDirFunc) error {
+		return fs.WalkDir(bundledSchemes, root, fn)
+	})
+
+	index.Prune(seen)
+	_ = index.Write()
+
+	// The active theme moves to the fetched copy of a bundled theme it hides
+	if key, ok := hidden[state.ActiveTheme]; ok {
+		state.ActiveTheme = key
+		_ = WriteState(state)
+	}
 
 	themeHooks := GetThemeHooks()
+	themeData := GetThemeData()
+	migrateThemeNames(themes, themeHooks, &themeData)
 
-	_ = filepath.WalkDir(config.Paths.CustomSchemes, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	themeList := []list.Item{}
+
+	for _, theme := range themes {
+		key := theme.Key().String()
+
+		theme.Hook = themeHooks[key]
+		theme.Tags = themeData.Tags[key]
+		theme.Favorite = slices.Contains(themeData.Favorites, key)
+		theme.Recent = slices.Index(themeData.Recent, key) + 1
+		theme.Active = theme.Key() == state.ActiveTheme
+		theme.Duplicate = names[theme.Name] > 1
+
+		themeList = append(themeList, theme)
+	}
+
+	return themeList
+}
+
+// migrateThemeNames rewrites hooks and theme data that were stored by theme
+// name to use theme keys. A name refers to the first theme with that name.
+func migrateThemeNames(themes []Theme, hooks map[string]string, themeData *ThemeData) {
+	keys := make(map[string]string)
+	for _, theme := range themes {
+		if _, exists := keys[theme.Name]; !exists {
+			keys[theme.Name] = theme.Key().String()
+		}
+	}
+
+	migrate := func(name string) (string, bool) {
+		if strings.Contains(name, "/") {
+			return name, false
 		}
 
-		if strings.Contains(d.Name(), ".yaml") {
+		key, exists := keys[name]
+		return key, exists
+	}
+
+	hooksChanged := false
 
-			name := strings.Split(d.Name(), ".")[0]
+	for name, hook := range hooks {
+		if key, ok := migrate(name); ok {
+			hooks[key] = hook
+			delete(hooks, name)
+			hooksChanged = true
+		}
+	}
 
-			if strings.Contains(string(activeThemePath), d.Name()) {
-				themeList = append(themeList, Theme{Name: name, Path: path, Active: true, Hook: themeHooks[name]})
-			} else {
-				themeList = append(themeList, Theme{Name: name, Path: path, Active: false, Hook: themeHooks[name]})
+	dataChanged := false
+
+	for _, list := range [][]string{themeData.Favorites, themeData.Recent} {
+		for i, name := range list {
+			if key, ok := migrate(name); ok {
+				list[i] = key
+				dataChanged = true
 			}
 		}
-		return nil
-	})
+	}
 
-	_ = filepath.WalkDir(filepath.Join(config.Paths.BaseSchemes, "tinted-theming", "base16"), func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	for name, tags := range themeData.Tags {
+		if key, ok := migrate(name); ok {
+			themeData.Tags[key] = tags
+			delete(themeData.Tags, name)
+			dataChanged = true
 		}
+	}
 
-		if strings.Contains(d.Name(), ".yaml") {
+	if hooksChanged {
+		WriteThemeHooks(hooks)
+	}
 
-			name := strings.Split(d.Name(), ".")[0]
+	if dataChanged {
+		WriteThemeData(*themeData)
+	}
+}
 
-			if strings.Contains(string(activeThemePath), d.Name()) {
-				themeList = append(themeList, Theme{Name: name, Path: path, Active: true, Hook: themeHooks[name]})
-			} else {
-				themeList = append(themeList, Theme{Name: name, Path: path, Active: false, Hook: themeHooks[name]})
-			}
+// FindTheme finds a theme by its key (source/slug) or by name. If several
+// themes share the name the first is used.
+func FindTheme(name string) (Theme, bool) {
+	return findThemeIn(GetThemes(), name)
+}
+
+func findThemeIn(themes []list.Item, name string) (Theme, bool) {
+	key, isKey := ParseThemeKey(name)
+
+	for _, item := range themes {
+		theme := item.(Theme)
+
+		if isKey && theme.Key() == key || !isKey && theme.Name == name {
+			return theme, true
 		}
-		return nil
-	})
+	}
 
-	return themeList
+	return Theme{}, false
 }
 
 func CreateTheme(themeName string, themeList []list.Item) tea.Cmd {
@@ -434,9 +567,9 @@ func CreateTheme(themeName string, themeList []list.Item) tea.Cmd {
 			return nil
 		}
 
-		activeThemePath, _ := os.ReadFile(config.Paths.ActiveTheme)
+		activeTheme := ReadState().ActiveTheme
 
-		themeData, _ := os.ReadFile(string(activeThemePath))
+		themeData, _ := ReadSchemeFile(activeTheme.Path())
 		if string(themeData) == "" {
 			themeData = CreateDefaultScheme(themeName)
 		}
@@ -458,6 +591,159 @@ func CreateTheme(themeName string, themeList []list.Item) tea.Cmd {
 	}
 }
 
+// SaveScheme writes an edited scheme, re-applying it when it is the active theme.
+func SaveScheme(path string, scheme builder.Scheme) tea.Cmd {
+	return func() tea.Msg {
+		err := WriteScheme(path, scheme)
+		if err != nil {
+			panic(err)
+		}
+
+		themeList := GetThemes()
+
+		for i, item := range themeList {
+			theme := item.(Theme)
+			if theme.Path != path || !theme.Active {
+				continue
+			}
+
+			if _, err := applyTheme(theme); err != nil {
+				theme.Err = true
+				themeList[i] = theme
+			}
+		}
+
+		return updateThemeListMsg(themeList)
+	}
+}
+
+func WriteScheme(path string, scheme builder.Scheme) error {
+	node := yaml.Node{}
+
+	err := node.Encode(&scheme)
+	if err != nil {
+		return err
+	}
+
+	// Keep the palette in base16 order rather than the sorted map order
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "palette" {
+			continue
+		}
+
+		palette := node.Content[i+1].Content
+		pairs := make([][]*yaml.Node, 0, len(palette)/2)
+		for j := 0; j+1 < len(palette); j += 2 {
+			pairs = append(pairs, palette[j:j+2])
+		}
+
+		slices.SortStableFunc(pairs, func(a, b []*yaml.Node) int {
+			return cmp.Compare(paletteOrder(a[0].Value), paletteOrder(b[0].Value))
+		})
+
+		node.Content[i+1].Content = slices.Concat(pairs...)
+	}
+
+	d, err := yaml.Marshal(&node)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, d, 0666)
+}
+
+func ReadThemeScheme(theme Theme) (builder.Scheme, error) {
+	scheme := builder.Scheme{}
+
+	data, err := ReadSchemeFile(theme.Path)
+	if err != nil {
+		return scheme, err
+	}
+
+	err = yaml.Unmarshal(data, &scheme)
+	return scheme, err
+}
+
+// SaveCustomScheme saves a generated scheme as a new custom theme.
+func SaveCustomScheme(name string, scheme builder.Scheme) (string, error) {
+	if name == "" || !validateFilename(name) {
+		return "", fmt.Errorf("Invalid theme name %q", name)
+	}
+
+	path := filepath.Join(config.Paths.CustomSchemes, name+".yaml")
+
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("Theme %q already exists", name)
+	}
+
+	return path, WriteScheme(path, scheme)
+}
+
+// uniqueCustomName adds a number to the name if a custom theme already has it.
+func uniqueCustomName(name string) string {
+	unique := name
+
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(config.Paths.CustomSchemes, unique+".yaml")); err != nil {
+			return unique
+		}
+		unique = name + "-" + strconv.Itoa(i)
+	}
+}
+
+// InvertTheme saves the opposite variant of a theme as a new custom theme.
+func InvertTheme(theme Theme) tea.Cmd {
+	return func() tea.Msg {
+		scheme, err := ReadThemeScheme(theme)
+		if err == nil {
+			scheme, err = builder.InvertScheme(scheme)
+		}
+
+		themeList := GetThemes()
+
+		if err != nil {
+			for i, item := range themeList {
+				if item.(Theme).Key() == theme.Key() {
+					theme.Err = true
+					themeList[i] = theme
+				}
+			}
+			return updateThemeListMsg(themeList)
+		}
+
+		_, err = SaveCustomScheme(uniqueCustomName(invertedName(theme.Name, scheme.Variant)), scheme)
+		if err != nil {
+			panic(err)
+		}
+
+		return updateThemeListMsg(GetThemes())
+	}
+}
+
+func invertedName(name string, variant string) string {
+	name = slug.Make(name)
+
+	for _, suffix := range []string{"-dark", "-light"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+
+	return name + "-" + variant
+}
+
+func paletteOrder(key string) int {
+	i := slices.Index(builder.Base16Keys, key)
+	if i == -1 {
+		return len(builder.Base16Keys)
+	}
+
+	return i
+}
+
 func EditTheme(prevTheme Theme, newName string, newHook string) tea.Cmd {
 	return func() tea.Msg {
 		prevPath := prevTheme.Path
@@ -468,10 +754,31 @@ func EditTheme(prevTheme Theme, newName string, newHook string) tea.Cmd {
 			panic(err)
 		}
 
+		prevKey := prevTheme.Key()
+		newKey := ThemeKey{Source: prevKey.Source, Slug: path.Join(path.Dir(prevKey.Slug), newName)}
+
 		hooks := GetThemeHooks()
-		hooks[prevTheme.Name] = newHook
+		delete(hooks, prevKey.String())
+		if newHook != "" {
+			hooks[newKey.String()] = newHook
+		}
 		WriteThemeHooks(hooks)
 
+		if newKey != prevKey {
+			themeData := GetThemeData()
+			themeData.Rename(prevKey.String(), newKey.String())
+			WriteThemeData(themeData)
+
+			state := ReadState()
+			if state.ActiveTheme == prevKey {
+				state.ActiveTheme = newKey
+				err = WriteState(state)
+				if err != nil {
+					panic(err)
+				}
+			}
+		}
+
 		themeList := GetThemes()
 		return updateThemeListMsg(themeList)
 	}
@@ -518,35 +825,129 @@ func WriteThemeHooks(themeHooksMap map[string]string) {
 	}
 }
 
-func GitCloneSchemes() tea.Cmd {
-	return func() tea.Msg {
-		repo := "https://github.com/tinted-theming/schemes.git"
-		target := filepath.Join(config.Paths.BaseSchemes, "tinted-theming")
+const recentThemesLimit = 5
 
-		err := os.RemoveAll(target)
-		if err != nil {
-			return nil
+type ThemeData struct {
+	Favorites []string            `yaml:"favorites,omitempty"`
+	Tags      map[string][]string `yaml:"tags,omitempty"`
+	Recent    []string            `yaml:"recent,omitempty"`
+}
+
+func GetThemeData() ThemeData {
+	themeData := ThemeData{}
+
+	data, err := os.ReadFile(config.Paths.ThemeData)
+	if errors.Is(err, fs.ErrNotExist) {
+		themeData.Tags = make(map[string][]string)
+		return themeData
+	}
+
+	err = yaml.Unmarshal([]byte(data), &themeData)
+	if err != nil {
+		panic(err)
+	}
+
+	if themeData.Tags == nil {
+		themeData.Tags = make(map[string][]string)
+	}
+
+	return themeData
+}
+
+func WriteThemeData(themeData ThemeData) {
+	d, err := yaml.Marshal(&themeData)
+	if err != nil {
+		panic(err)
+	}
+
+	err = os.WriteFile(config.Paths.ThemeData, d, 0666)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (d *ThemeData) Rename(prevName, newName string) {
+	for i, name := range d.Favorites {
+		if name == prevName {
+			d.Favorites[i] = newName
 		}
+	}
 
-		cmd := exec.Command("git", "clone", repo, target)
-		err = cmd.Run()
-		if err != nil {
-			return nil
+	for i, name := range d.Recent {
+		if name == prevName {
+			d.Recent[i] = newName
+		}
+	}
+
+	if tags, exists := d.Tags[prevName]; exists {
+		d.Tags[newName] = tags
+		delete(d.Tags, prevName)
+	}
+}
+
+func RecordRecentTheme(key ThemeKey) error {
+	themeData := GetThemeData()
+
+	themeData.Recent = slices.DeleteFunc(themeData.Recent, func(v string) bool { return v == key.String() })
+	themeData.Recent = slices.Insert(themeData.Recent, 0, key.String())
+
+	if len(themeData.Recent) > recentThemesLimit {
+		themeData.Recent = themeData.Recent[:recentThemesLimit]
+	}
+
+	d, err := yaml.Marshal(&themeData)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.Paths.ThemeData, d, 0666)
+}
+
+func ToggleFavoriteTheme(theme Theme) tea.Cmd {
+	return func() tea.Msg {
+		themeData := GetThemeData()
+
+		key := theme.Key().String()
+
+		if theme.Favorite {
+			themeData.Favorites = slices.DeleteFunc(themeData.Favorites, func(v string) bool { return v == key })
+		} else {
+			themeData.Favorites = append(themeData.Favorites, key)
 		}
 
+		WriteThemeData(themeData)
+
 		themeList := GetThemes()
+		return updateThemeListMsg(themeList)
+	}
+}
+
+func EditThemeTags(theme Theme, tags []string) tea.Cmd {
+	return func() tea.Msg {
+		themeData := GetThemeData()
 
+		key := theme.Key().String()
+
+		if len(tags) == 0 {
+			delete(themeData.Tags, key)
+		} else {
+			themeData.Tags[key] = tags
+		}
+
+		WriteThemeData(themeData)
+
+		themeList := GetThemes()
 		return updateThemeListMsg(themeList)
 	}
 }
 
 func GetActiveColors() Colors {
-	activeTheme, err := os.ReadFile(config.Paths.ActiveTheme)
-	if err != nil {
+	activeTheme := ReadState().ActiveTheme
+	if activeTheme.IsZero() {
 		return DefaultColors()
 	}
 
-	file, err := os.ReadFile(string(activeTheme))
+	file, err := ReadSchemeFile(activeTheme.Path())
 	if err != nil {
 		return DefaultColors()
 	}
@@ -558,36 +959,48 @@ func GetActiveColors() Colors {
 		return DefaultColors()
 	}
 
-	if len(scheme.Palette) != 16 {
+	colors, err := PaletteColors(scheme.Palette)
+	if err != nil {
 		return DefaultColors()
 	}
 
-	for i, clr := range scheme.Palette {
+	return colors
+}
+
+func PaletteColors(palette map[string]string) (Colors, error) {
+	hex := make(map[string]lipgloss.Color)
+
+	for _, key := range builder.Base16Keys {
+		clr, ok := palette[key]
+		if !ok {
+			return Colors{}, errors.New("Invalid palette")
+		}
+
 		c, err := builder.ParseHexColor(clr)
 		if err != nil {
-			return DefaultColors()
+			return Colors{}, err
 		}
-		scheme.Palette[i] = fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+		hex[key] = lipgloss.Color(builder.HexColor(c))
 	}
 
 	return Colors{
-		Base00: lipgloss.Color("#" + scheme.Palette["base00"]),
-		Base01: lipgloss.Color("#" + scheme.Palette["base01"]),
-		Base02: lipgloss.Color("#" + scheme.Palette["base02"]),
-		Base03: lipgloss.Color("#" + scheme.Palette["base03"]),
-		Base04: lipgloss.Color("#" + scheme.Palette["base04"]),
-		Base05: lipgloss.Color("#" + scheme.Palette["base05"]),
-		Base06: lipgloss.Color("#" + scheme.Palette["base06"]),
-		Base07: lipgloss.Color("#" + scheme.Palette["base07"]),
-		Base08: lipgloss.Color("#" + scheme.Palette["base08"]),
-		Base09: lipgloss.Color("#" + scheme.Palette["base09"]),
-		Base0A: lipgloss.Color("#" + scheme.Palette["base0A"]),
-		Base0B: lipgloss.Color("#" + scheme.Palette["base0B"]),
-		Base0C: lipgloss.Color("#" + scheme.Palette["base0C"]),
-		Base0D: lipgloss.Color("#" + scheme.Palette["base0D"]),
-		Base0E: lipgloss.Color("#" + scheme.Palette["base0E"]),
-		Base0F: lipgloss.Color("#" + scheme.Palette["base0F"]),
-	}
+		Base00: hex["base00"],
+		Base01: hex["base01"],
+		Base02: hex["base02"],
+		Base03: hex["base03"],
+		Base04: hex["base04"],
+		Base05: hex["base05"],
+		Base06: hex["base06"],
+		Base07: hex["base07"],
+		Base08: hex["base08"],
+		Base09: hex["base09"],
+		Base0A: hex["base0A"],
+		Base0B: hex["base0B"],
+		Base0C: hex["base0C"],
+		Base0D: hex["base0D"],
+		Base0E: hex["base0E"],
+		Base0F: hex["base0F"],
+	}, nil
 }
 
 func UpdateActiveStyles() tea.Msg {
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTestConfig points every path of the config into a temporary directory
+// with a single local source, restoring the config after the test.
+func useTestConfig(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	saved := config
+	t.Cleanup(func() { config = saved })
+
+	home := filepath.Join(root, "config")
+	data := filepath.Join(root, "data")
+
+	config.Sources = []Source{{Name: "local", Dir: filepath.Join(root, "local")}}
+	config.Paths = Paths{
+		Home:              home,
+		Apps:              filepath.Join(home, "apps.yaml"),
+		Templates:         filepath.Join(home, "templates"),
+		State:             filepath.Join(home, "state.yaml"),
+		LegacyActiveTheme: filepath.Join(home, "activeTheme"),
+		ThemeHooks:        filepath.Join(home, "themeHooks.yaml"),
+		ThemeData:         filepath.Join(home, "themeData.yaml"),
+		CustomSchemes:     filepath.Join(home, "schemes"),
+		BaseSchemes:       filepath.Join(data, "schemes"),
+		ThemeIndex:        filepath.Join(data, "themeIndex.yaml"),
+		ScheduleState:     filepath.Join(data, "schedule.yaml"),
+		ShellInit:         filepath.Join(data, "shell"),
+		Socket:            filepath.Join(root, "pin.sock"),
+	}
+
+	for _, dir := range []string{home, data, filepath.Join(root, "local")} {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func TestGetThemesHiddenBundledActive(t *testing.T) {
+	root := useTestConfig(t)
+
+	bundledNord := ThemeKey{Source: bundledSource, Slug: "base16/nord"}
+	if err := WriteState(State{ActiveTheme: bundledNord}); err != nil {
+		t.Fatal(err)
+	}
+
+	theme, found := FindTheme(bundledNord.String())
+	if !found || !theme.Active {
+		t.Fatalf("bundled nord = %v, %v, want it found and active", theme.Key(), theme.Active)
+	}
+
+	// Fetching a scheme with the same name hides the bundled copy
+	nord, err := bundledSchemes.ReadFile(bundledNord.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "local", "nord.yaml"), nord, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	localNord := ThemeKey{Source: "local", Slug: "nord"}
+
+	active := []ThemeKey{}
+	for _, item := range GetThemes() {
+		theme := item.(Theme)
+
+		if theme.Key() == bundledNord {
+			t.Error("bundled nord is listed next to the fetched nord")
+		}
+
+		if theme.Active {
+			active = append(active, theme.Key())
+		}
+	}
+
+	if len(active) != 1 || active[0] != localNord {
+		t.Errorf("active themes = %v, want [%v]", active, localNord)
+	}
+
+	if state := ReadState(); state.ActiveTheme != localNord {
+		t.Errorf("active theme in state = %v, want %v", state.ActiveTheme, localNord)
+	}
+
+	if _, found := FindTheme(ReadState().ActiveTheme.String()); !found {
+		t.Error("the active theme can't be found after fetching")
+	}
+}
+
+func TestGetThemesBundledFallback(t *testing.T) {
+	useTestConfig(t)
+
+	// Every bundled theme is listed until a scheme with its name is fetched
+	names := map[string]int{}
+	for _, item := range GetThemes() {
+		theme := item.(Theme)
+		if theme.Source != bundledSource {
+			t.Errorf("%s is listed without any schemes fetched", theme.Key())
+		}
+		names[theme.Name]++
+	}
+
+	if names["nord"] == 0 {
+		t.Error("bundled nord is not listed")
+	}
+}
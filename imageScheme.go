@@ -0,0 +1,295 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/ClaraSmyth/pin/builder"
+)
+
+// Schemes are generated from images in three steps. The pixels are quantised
+// into a small palette with median cut, the most common colour tints the
+// base00 to base07 ramp, and the accents base08 to base0F are taken from the
+// palette colours closest to the hue each base16 role expects.
+
+const (
+	imageSamples = 100_000
+	imageColors  = 16
+)
+
+// The hues of base08 to base0F: red, orange, yellow, green, cyan, blue, magenta, brown.
+var accentHues = []float64{0, 30, 55, 120, 180, 220, 290, 20}
+
+type paletteColor struct {
+	Color color.RGBA
+	Count int
+}
+
+func SchemeFromImage(path string, name string, variant string) (builder.Scheme, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return builder.Scheme{}, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return builder.Scheme{}, err
+	}
+
+	pixels := samplePixels(img)
+	if len(pixels) == 0 {
+		return builder.Scheme{}, errors.New("The image has no opaque pixels")
+	}
+
+	if variant == "" {
+		variant = "dark"
+		if averageLightness(pixels) > 0.5 {
+			variant = "light"
+		}
+	}
+
+	if variant != "dark" && variant != "light" {
+		return builder.Scheme{}, errors.New("Variant must be dark or light")
+	}
+
+	palette := medianCut(pixels, imageColors)
+
+	scheme := builder.Scheme{
+		System:      "base16",
+		Name:        name,
+		Author:      "pin",
+		Description: "Generated from " + path,
+		Variant:     variant,
+		Palette:     make(map[string]string),
+	}
+
+	for i, c := range imageRamp(palette, variant) {
+		scheme.Palette[builder.Base16Keys[i]] = builder.HexColor(c)
+	}
+
+	for i, c := range imageAccents(palette, variant) {
+		scheme.Palette[builder.Base16Keys[8+i]] = builder.HexColor(c)
+	}
+
+	return scheme, nil
+}
+
+// samplePixels returns the opaque pixels of the image, skipping pixels evenly
+// on large images.
+func samplePixels(img image.Image) []color.RGBA {
+	bounds := img.Bounds()
+	step := max(1, int(math.Sqrt(float64(bounds.Dx()*bounds.Dy())/imageSamples)))
+
+	pixels := []color.RGBA{}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+
+			pixels = append(pixels, color.RGBA{R: c.R, G: c.G, B: c.B, A: 255})
+		}
+	}
+
+	return pixels
+}
+
+func averageLightness(pixels []color.RGBA) float64 {
+	total := 0.0
+
+	for _, c := range pixels {
+		_, _, l := builder.RGBToHSL(c)
+		total += l
+	}
+
+	return total / float64(len(pixels))
+}
+
+// medianCut splits the pixels into n boxes, always splitting the box with the
+// widest channel at its median, and returns the average colour of each box.
+func medianCut(pixels []color.RGBA, n int) []paletteColor {
+	boxes := [][]color.RGBA{pixels}
+
+	for len(boxes) < n {
+		widest, channel, widestRange := -1, 0, 0
+
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+
+			c, r := widestChannel(box)
+			if r > widestRange {
+				widest, channel, widestRange = i, c, r
+			}
+		}
+
+		if widest == -1 {
+			break
+		}
+
+		box := boxes[widest]
+		slices.SortFunc(box, func(a, b color.RGBA) int {
+			return cmp.Compare(channelValue(a, channel), channelValue(b, channel))
+		})
+
+		mid := len(box) / 2
+		boxes[widest] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	palette := []paletteColor{}
+
+	for _, box := range boxes {
+		var r, g, b int
+		for _, c := range box {
+			r += int(c.R)
+			g += int(c.G)
+			b += int(c.B)
+		}
+
+		count := len(box)
+		palette = append(palette, paletteColor{
+			Color: color.RGBA{R: uint8(r / count), G: uint8(g / count), B: uint8(b / count), A: 255},
+			Count: count,
+		})
+	}
+
+	slices.SortFunc(palette, func(a, b paletteColor) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+
+	return palette
+}
+
+func widestChannel(box []color.RGBA) (int, int) {
+	widest, widestRange := 0, -1
+
+	for channel := 0; channel < 3; channel++ {
+		low, high := 255, 0
+		for _, c := range box {
+			v := int(channelValue(c, channel))
+			low = min(low, v)
+			high = max(high, v)
+		}
+
+		if high-low > widestRange {
+			widest, widestRange = channel, high-low
+		}
+	}
+
+	return widest, widestRange
+}
+
+func channelValue(c color.RGBA, channel int) uint8 {
+	switch channel {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	default:
+		return c.B
+	}
+}
+
+// imageRamp builds base00 to base07, from the background to the brightest
+// foreground, tinted with the most common colour of the image.
+func imageRamp(palette []paletteColor, variant string) []color.RGBA {
+	h, s, _ := builder.RGBToHSL(palette[0].Color)
+	s = math.Min(s, 0.25)
+
+	// Lightness of each step on a dark background, light schemes are mirrored
+	ramp := []float64{0.10, 0.14, 0.20, 0.40, 0.62, 0.82, 0.90, 0.96}
+
+	colors := []color.RGBA{}
+
+	for i, l := range ramp {
+		// Backgrounds are tinted more than foregrounds
+		tint := s
+		if i >= 4 {
+			tint = s / 2
+		}
+
+		if variant == "light" {
+			l = 1 - l + 0.04
+		}
+
+		colors = append(colors, builder.HSLToRGB(h, tint, l))
+	}
+
+	return colors
+}
+
+// imageAccents picks the palette colour closest to each accent hue and adjusts
+// its lightness so it is readable on the background. Roles with no colour
+// near their hue in the image get the hue with the image's average saturation.
+func imageAccents(palette []paletteColor, variant string) []color.RGBA {
+	saturation := 0.0
+	total := 0
+
+	for _, p := range palette {
+		_, s, _ := builder.RGBToHSL(p.Color)
+		saturation += s * float64(p.Count)
+		total += p.Count
+	}
+
+	saturation = math.Max(saturation/float64(total), 0.45)
+
+	lightness := 0.68
+	if variant == "light" {
+		lightness = 0.42
+	}
+
+	colors := []color.RGBA{}
+
+	for i, hue := range accentHues {
+		h, s, l := hue, saturation, lightness
+		closest := 45.0
+
+		for _, p := range palette {
+			ph, ps, pl := builder.RGBToHSL(p.Color)
+			if ps < 0.2 || pl < 0.1 || pl > 0.9 {
+				continue
+			}
+
+			if d := hueDistance(ph, hue); d < closest {
+				closest = d
+				h, s = ph, ps
+				l = pl
+			}
+		}
+
+		s = math.Min(math.Max(s, 0.4), 0.85)
+
+		if variant == "light" {
+			l = math.Min(math.Max(l, 0.32), 0.48)
+		} else {
+			l = math.Min(math.Max(l, 0.6), 0.76)
+		}
+
+		// base0F is a darker, muted brown
+		if i == 7 {
+			s *= 0.6
+			l *= 0.8
+		}
+
+		colors = append(colors, builder.HSLToRGB(h, s, l))
+	}
+
+	return colors
+}
+
+func hueDistance(a, b float64) float64 {
+	d := math.Abs(a - b)
+	return math.Min(d, 360-d)
+}
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ClaraSmyth/pin/builder"
+	"gopkg.in/yaml.v3"
+)
+
+// Terminal colour schemes are read into TerminalColors, then the ANSI colours
+// are mapped onto the base16 roles. The background and foreground anchor the
+// base00 to base07 ramp and the normal ANSI colours become the accents.
+
+var importFormats = []string{"xresources", "kitty", "alacritty", "alacritty-yaml", "windows-terminal", "iterm", "gogh"}
+
+// ANSI colour names in order, as used by Alacritty and Windows Terminal.
+var ansiNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+type TerminalColors struct {
+	Name       string
+	Author     string
+	Background *color.RGBA
+	Foreground *color.RGBA
+	Selection  *color.RGBA
+	ANSI       [16]*color.RGBA
+}
+
+// set parses a colour by its name in any of the supported formats.
+func (t *TerminalColors) set(name string, value string) error {
+	c, err := parseTerminalColor(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	name = strings.ToLower(name)
+
+	switch name {
+	case "background":
+		t.Background = &c
+	case "foreground":
+		t.Foreground = &c
+	case "selection_background", "selectionbackground":
+		t.Selection = &c
+	default:
+		if i := ansiIndex(name); i >= 0 {
+			t.ANSI[i] = &c
+		}
+	}
+
+	return nil
+}
+
+// ansiIndex returns the ANSI number of names like color4, color_05 or brightBlue.
+func ansiIndex(name string) int {
+	if number, found := strings.CutPrefix(name, "color"); found {
+		number = strings.TrimPrefix(number, "_")
+
+		i, err := strconv.Atoi(number)
+		if err != nil {
+			return -1
+		}
+
+		// Gogh numbers its colours from 1
+		if strings.HasPrefix(name, "color_") {
+			i--
+		}
+
+		if i >= 0 && i < 16 {
+			return i
+		}
+
+		return -1
+	}
+
+	bright := strings.HasPrefix(name, "bright")
+	name = strings.TrimPrefix(name, "bright")
+
+	// Windows Terminal calls magenta purple
+	if name == "purple" {
+		name = "magenta"
+	}
+
+	if i := slices.Index(ansiNames, name); i >= 0 {
+		if bright {
+			return i + 8
+		}
+		return i
+	}
+
+	return -1
+}
+
+var rgbColorRegex = regexp.MustCompile(`^rgb:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})$`)
+
+// parseTerminalColor parses #rrggbb, 0xrrggbb and X11 rgb:rr/gg/bb colours.
+func parseTerminalColor(value string) (color.RGBA, error) {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+	if match := rgbColorRegex.FindStringSubmatch(value); match != nil {
+		c := color.RGBA{A: 255}
+		channels := []*uint8{&c.R, &c.G, &c.B}
+
+		for i, hex := range match[1:] {
+			v, _ := strconv.ParseUint(hex, 16, 16)
+			maxValue := math.Pow(16, float64(len(hex))) - 1
+			*channels[i] = uint8(math.Round(float64(v) / maxValue * 255))
+		}
+
+		return c, nil
+	}
+
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+
+	return builder.ParseHexColor(value)
+}
+
+func ImportScheme(path string, data []byte, format string) (builder.Scheme, error) {
+	if format == "" {
+		format = detectImportFormat(path, data)
+	}
+
+	var colors TerminalColors
+	var err error
+
+	switch format {
+	case "xresources":
+		colors, err = parseXresources(data)
+	case "kitty":
+		colors, err = parseKitty(data)
+	case "alacritty":
+		colors, err = parseAlacrittyTOML(data)
+	case "alacritty-yaml":
+		colors, err = parseAlacrittyYAML(data)
+	case "windows-terminal":
+		colors, err = parseWindowsTerminal(data)
+	case "iterm":
+		colors, err = parseITerm(data)
+	case "gogh":
+		colors, err = parseGogh(data)
+	case "":
+		return builder.Scheme{}, errors.New("Could not detect the format, use --format")
+	default:
+		return builder.Scheme{}, fmt.Errorf("Unknown format %q, expected one of %s", format, strings.Join(importFormats, ", "))
+	}
+
+	if err != nil {
+		return builder.Scheme{}, err
+	}
+
+	if colors.Name == "" {
+		colors.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return terminalScheme(colors)
+}
+
+func detectImportFormat(path string, data []byte) string {
+	base := strings.ToLower(filepath.Base(path))
+
+	switch filepath.Ext(base) {
+	case ".itermcolors":
+		return "iterm"
+	case ".toml":
+		return "alacritty"
+	case ".yml", ".yaml":
+		return "alacritty-yaml"
+	case ".conf":
+		return "kitty"
+	case ".json":
+		if bytes.Contains(data, []byte(`"color_01"`)) {
+			return "gogh"
+		}
+		return "windows-terminal"
+	}
+
+	switch {
+	case strings.Contains(base, "xresources"), strings.Contains(base, "xdefaults"):
+		return "xresources"
+	case bytes.Contains(data, []byte("<plist")):
+		return "iterm"
+	case regexp.MustCompile(`(?m)^\S*[.*]color\d+\s*:`).Match(data):
+		return "xresources"
+	case regexp.MustCompile(`(?m)^color\d+\s+\S`).Match(data):
+		return "kitty"
+	}
+
+	return ""
+}
+
+// lines calls fn with every line that isn't blank or a comment.
+func lines(data []byte, comment string, fn func(line string) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, comment) {
+			continue
+		}
+
+		err := fn(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+var xresourcesRegex = regexp.MustCompile(`^\S*?[.*]?(foreground|background|color\d+)\s*:\s*(\S+)`)
+
+func parseXresources(data []byte) (TerminalColors, error) {
+	colors := TerminalColors{}
+	defines := map[string]string{}
+
+	err := lines(data, "!", func(line string) error {
+		if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "#define" {
+			defines[fields[1]] = fields[2]
+			return nil
+		}
+
+		match := xresourcesRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil
+		}
+
+		value := match[2]
+		if defined, exists := defines[value]; exists {
+			value = defined
+		}
+
+		return colors.set(match[1], value)
+	})
+
+	return colors, err
+}
+
+func parseKitty(data []byte) (TerminalColors, error) {
+	colors := TerminalColors{}
+
+	err := lines(data, "#", func(line string) error {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil
+		}
+
+		switch name := fields[0]; {
+		case name == "foreground", name == "background", name == "selection_background", ansiIndex(name) >= 0:
+			return colors.set(name, fields[1])
+		}
+
+		return nil
+	})
+
+	return colors, err
+}
+
+// parseAlacrittyTOML reads the colour tables of an Alacritty config. Only the
+// simple key = "value" form Alacritty themes use is supported.
+func parseAlacrittyTOML(data []byte) (TerminalColors, error) {
+	colors := TerminalColors{}
+	section := ""
+
+	err := lines(data, "#", func(line string) error {
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			return nil
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil
+		}
+
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		// Drop trailing comments after quoted values
+		if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+			if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+				value = value[:end+2]
+			}
+		}
+
+		return colors.setAlacritty(section, key, value)
+	})
+
+	return colors, err
+}
+
+func parseAlacrittyYAML(data []byte) (TerminalColors, error) {
+	config := struct {
+		Colors map[string]map[string]string `yaml:"colors"`
+	}{}
+
+	err := yaml.Unmarshal(data, &config)
+	if err != nil {
+		return TerminalColors{}, err
+	}
+
+	colors := TerminalColors{}
+
+	for section, table := range config.Colors {
+		for key, value := range table {
+			err := colors.setAlacritty("colors."+section, key, value)
+			if err != nil {
+				return colors, err
+			}
+		}
+	}
+
+	return colors, nil
+}
+
+func (t *TerminalColors) setAlacritty(section string, key string, value string) error {
+	switch section {
+	case "colors.primary":
+		if key == "background" || key == "foreground" {
+			return t.set(key, value)
+		}
+	case "colors.selection":
+		if key == "background" {
+			return t.set("selection_background", value)
+		}
+	case "colors.normal":
+		if slices.Contains(ansiNames, key) {
+			return t.set(key, value)
+		}
+	case "colors.bright":
+		if slices.Contains(ansiNames, key) {
+			return t.set("bright"+key, value)
+		}
+	}
+
+	return nil
+}
+
+// parseWindowsTerminal reads a scheme object, or the first scheme of a settings file.
+func parseWindowsTerminal(data []byte) (TerminalColors, error) {
+	settings := struct {
+		Schemes []map[string]any `json:"schemes"`
+	}{}
+
+	scheme := map[string]any{}
+
+	if json.Unmarshal(data, &settings) == nil && len(settings.Schemes) > 0 {
+		scheme = settings.Schemes[0]
+	} else if err := json.Unmarshal(data, &scheme); err != nil {
+		return TerminalColors{}, err
+	}
+
+	return jsonColors(scheme)
+}
+
+func parseGogh(data []byte) (TerminalColors, error) {
+	scheme := map[string]any{}
+
+	err := json.Unmarshal(data, &scheme)
+	if err != nil {
+		return TerminalColors{}, err
+	}
+
+	return jsonColors(scheme)
+}
+
+func jsonColors(scheme map[string]any) (TerminalColors, error) {
+	colors := TerminalColors{}
+
+	for key, value := range scheme {
+		value, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "name":
+			colors.Name = value
+		case "author":
+			colors.Author = value
+		case "cursor", "cursorColor":
+		default:
+			name := strings.ToLower(key)
+			if name == "foreground" || name == "background" || name == "selectionbackground" || ansiIndex(name) >= 0 {
+				err := colors.set(name, value)
+				if err != nil {
+					return colors, err
+				}
+			}
+		}
+	}
+
+	return colors, nil
+}
+
+// parseITerm reads an .itermcolors plist, a dict of colour names to dicts of
+// colour components between 0 and 1.
+func parseITerm(data []byte) (TerminalColors, error) {
+	colors := TerminalColors{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	depth := 0
+	colorName := ""
+	component := ""
+	lastKey := ""
+	components := map[string]float64{}
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return colors, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "dict":
+				depth++
+				if depth == 2 {
+					colorName = lastKey
+					components = map[string]float64{}
+				}
+			case "key":
+				var key string
+				err := decoder.DecodeElement(&key, &token)
+				if err != nil {
+					return colors, err
+				}
+				lastKey = key
+				component = key
+			case "real", "integer":
+				var value float64
+				err := decoder.DecodeElement(&value, &token)
+				if err != nil {
+					return colors, err
+				}
+				if depth == 2 {
+					components[component] = value
+				}
+			}
+
+		case xml.EndElement:
+			if token.Name.Local != "dict" {
+				continue
+			}
+
+			if depth == 2 {
+				err := colors.setITerm(colorName, components)
+				if err != nil {
+					return colors, err
+				}
+			}
+			depth--
+		}
+	}
+
+	return colors, nil
+}
+
+func (t *TerminalColors) setITerm(name string, components map[string]float64) error {
+	hex := fmt.Sprintf("#%02x%02x%02x",
+		uint8(math.Round(components["Red Component"]*255)),
+		uint8(math.Round(components["Green Component"]*255)),
+		uint8(math.Round(components["Blue Component"]*255)),
+	)
+
+	switch name {
+	case "Background Color":
+		return t.set("background", hex)
+	case "Foreground Color":
+		return t.set("foreground", hex)
+	case "Selection Color":
+		return t.set("selection_background", hex)
+	}
+
+	if number, found := strings.CutPrefix(name, "Ansi "); found {
+		number = strings.TrimSuffix(number, " Color")
+		return t.set("color"+number, hex)
+	}
+
+	return nil
+}
+
+// terminalScheme maps terminal colours to base16. base00 and base05 are the
+// background and foreground, the rest of the ramp is mixed between them with
+// the selection and bright black used where they exist. Orange and brown have
+// no ANSI colour so they are mixed from red, yellow and the background.
+func terminalScheme(colors TerminalColors) (builder.Scheme, error) {
+	if colors.Background == nil || colors.Foreground == nil {
+		return builder.Scheme{}, errors.New("The scheme needs a background and foreground colour")
+	}
+
+	ansi := [16]color.RGBA{}
+
+	for i := 0; i < 16; i++ {
+		switch {
+		case colors.ANSI[i] != nil:
+			ansi[i] = *colors.ANSI[i]
+		case i >= 8 && colors.ANSI[i-8] != nil:
+			ansi[i] = *colors.ANSI[i-8]
+		default:
+			return builder.Scheme{}, fmt.Errorf("The scheme is missing ANSI colour %d", i)
+		}
+	}
+
+	bg, fg := *colors.Background, *colors.Foreground
+	mix := builder.MixColors
+
+	selection := mix(bg, fg, 0.16)
+	if colors.Selection != nil {
+		selection = *colors.Selection
+	}
+
+	// Bright black is often used for comments but some schemes set it to the
+	// background, or on light schemes to something darker than the foreground
+	comments := ansi[8]
+	if ratio := builder.ContrastRatio(comments, bg); ratio < 2 || ratio >= builder.ContrastRatio(fg, bg) {
+		comments = mix(bg, fg, 0.4)
+	}
+
+	// The end of the ramp is bright white on dark schemes and black on light ones
+	extreme := ansi[15]
+	if builder.RelativeLuminance(bg) > builder.RelativeLuminance(fg) {
+		extreme = ansi[0]
+	}
+	if builder.ContrastRatio(extreme, bg) < builder.ContrastRatio(fg, bg) {
+		extreme = fg
+	}
+
+	palette := []color.RGBA{
+		bg,
+		mix(bg, fg, 0.08),
+		selection,
+		comments,
+		mix(bg, fg, 0.7),
+		fg,
+		mix(fg, extreme, 0.5),
+		extreme,
+		ansi[1],
+		mix(ansi[1], ansi[3], 0.5),
+		ansi[3],
+		ansi[2],
+		ansi[6],
+		ansi[4],
+		ansi[5],
+		mix(ansi[1], bg, 0.4),
+	}
+
+	scheme := builder.Scheme{
+		System:  "base16",
+		Name:    colors.Name,
+		Author:  colors.Author,
+		Palette: make(map[string]string),
+	}
+
+	for i, c := range palette {
+		scheme.Palette[builder.Base16Keys[i]] = builder.HexColor(c)
+	}
+
+	scheme.Variant, _ = builder.InferVariant(scheme)
+
+	return scheme, nil
+}
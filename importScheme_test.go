@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ClaraSmyth/pin/builder"
+)
+
+// Tomorrow Night, written out in each format below
+var (
+	importBackground = "#1d1f21"
+	importForeground = "#c5c8c6"
+	importSelection  = "#373b41"
+	importANSI       = []string{
+		"#282a2e", "#a54242", "#8c9440", "#de935f", "#5f819d", "#85678f", "#5e8d87", "#707880",
+		"#373b41", "#cc6666", "#b5bd68", "#f0c674", "#81a2be", "#b294bb", "#8abeb7", "#c5c8c6",
+	}
+)
+
+func importXresources() string {
+	var b strings.Builder
+	b.WriteString("! Tomorrow Night\n#define bg #1d1f21\n")
+	fmt.Fprintf(&b, "*.background: bg\n*.foreground:  %s\n", importForeground)
+	for i, c := range importANSI {
+		fmt.Fprintf(&b, "*color%d: %s\n", i, c)
+	}
+	return b.String()
+}
+
+func importKitty() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Tomorrow Night\nbackground %s\nforeground %s\nselection_background %s\ncursor #ffffff\n", importBackground, importForeground, importSelection)
+	for i, c := range importANSI {
+		fmt.Fprintf(&b, "color%d   %s\n", i, c)
+	}
+	return b.String()
+}
+
+func importAlacrittyTOML() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[colors.primary]\nbackground = \"%s\" # comment\nforeground = '0x%s'\n\n", importBackground, importForeground[1:])
+	fmt.Fprintf(&b, "[colors.selection]\nbackground = \"%s\"\n\n[colors.normal]\n", importSelection)
+	for i, name := range ansiNames {
+		fmt.Fprintf(&b, "%s = \"%s\"\n", name, importANSI[i])
+	}
+	b.WriteString("\n[colors.bright]\n")
+	for i, name := range ansiNames {
+		fmt.Fprintf(&b, "%s = \"%s\"\n", name, importANSI[i+8])
+	}
+	return b.String()
+}
+
+func importAlacrittyYAML() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "colors:\n  primary:\n    background: '%s'\n    foreground: '%s'\n  normal:\n", importBackground, importForeground)
+	for i, name := range ansiNames {
+		fmt.Fprintf(&b, "    %s: '%s'\n", name, importANSI[i])
+	}
+	b.WriteString("  bright:\n")
+	for i, name := range ansiNames {
+		fmt.Fprintf(&b, "    %s: '0x%s'\n", name, importANSI[i+8][1:])
+	}
+	return b.String()
+}
+
+func importWindowsTerminal() string {
+	names := []string{"black", "red", "green", "yellow", "blue", "purple", "cyan", "white"}
+
+	fields := []string{`"name": "Tomorrow Night"`, `"background": "` + importBackground + `"`, `"foreground": "` + importForeground + `"`, `"selectionBackground": "` + importSelection + `"`, `"cursorColor": "#ffffff"`}
+	for i, name := range names {
+		fields = append(fields, fmt.Sprintf(`"%s": "%s"`, name, importANSI[i]))
+		fields = append(fields, fmt.Sprintf(`"bright%s": "%s"`, strings.ToUpper(name[:1])+name[1:], importANSI[i+8]))
+	}
+
+	return `{"profiles": {}, "schemes": [{` + strings.Join(fields, ", ") + `}]}`
+}
+
+func importGogh() string {
+	fields := []string{`"name": "Tomorrow Night"`, `"author": "Chris Kempson"`, `"background": "` + importBackground + `"`, `"foreground": "` + importForeground + `"`}
+	for i, c := range importANSI {
+		fields = append(fields, fmt.Sprintf(`"color_%02d": "%s"`, i+1, c))
+	}
+
+	return "{" + strings.Join(fields, ",\n") + "}"
+}
+
+func importITerm() string {
+	entry := func(name string, hex string) string {
+		c, _ := builder.ParseHexColor(hex)
+		return fmt.Sprintf("<key>%s</key>\n<dict>\n<key>Alpha Component</key><real>1</real>\n<key>Blue Component</key><real>%v</real>\n<key>Color Space</key><string>sRGB</string>\n<key>Green Component</key><real>%v</real>\n<key>Red Component</key><real>%v</real>\n</dict>\n",
+			name, float64(c.B)/255, float64(c.G)/255, float64(c.R)/255)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<plist version=\"1.0\">\n<dict>\n")
+	for i, c := range importANSI {
+		b.WriteString(entry(fmt.Sprintf("Ansi %d Color", i), c))
+	}
+	b.WriteString(entry("Background Color", importBackground))
+	b.WriteString(entry("Foreground Color", importForeground))
+	b.WriteString(entry("Selection Color", importSelection))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func TestImportScheme(t *testing.T) {
+	tests := []struct {
+		format string
+		path   string
+		data   string
+		name   string
+	}{
+		{format: "xresources", path: "tomorrow-night.Xresources", data: importXresources(), name: "tomorrow-night"},
+		{format: "kitty", path: "tomorrow-night.conf", data: importKitty(), name: "tomorrow-night"},
+		{format: "alacritty", path: "tomorrow-night.toml", data: importAlacrittyTOML(), name: "tomorrow-night"},
+		{format: "alacritty-yaml", path: "tomorrow-night.yml", data: importAlacrittyYAML(), name: "tomorrow-night"},
+		{format: "windows-terminal", path: "settings.json", data: importWindowsTerminal(), name: "Tomorrow Night"},
+		{format: "gogh", path: "tomorrow-night.json", data: importGogh(), name: "Tomorrow Night"},
+		{format: "iterm", path: "Tomorrow Night.itermcolors", data: importITerm(), name: "Tomorrow Night"},
+	}
+
+	// The accents map straight onto the normal ANSI colours
+	want := map[string]string{
+		"base00": importBackground,
+		"base05": importForeground,
+		"base08": importANSI[1],
+		"base0A": importANSI[3],
+		"base0B": importANSI[2],
+		"base0C": importANSI[6],
+		"base0D": importANSI[4],
+		"base0E": importANSI[5],
+	}
+
+	for _, test := range tests {
+		if detected := detectImportFormat(test.path, []byte(test.data)); detected != test.format {
+			t.Errorf("%s: detected format %q", test.format, detected)
+		}
+
+		scheme, err := ImportScheme(test.path, []byte(test.data), test.format)
+		if err != nil {
+			t.Errorf("%s: ImportScheme returned %v", test.format, err)
+			continue
+		}
+
+		if scheme.Name != test.name || scheme.System != "base16" || scheme.Variant != "dark" {
+			t.Errorf("%s: imported %q %s %s, want %q base16 dark", test.format, scheme.Name, scheme.System, scheme.Variant, test.name)
+		}
+
+		for key, value := range want {
+			if scheme.Palette[key] != value {
+				t.Errorf("%s: %s = %s, want %s", test.format, key, scheme.Palette[key], value)
+			}
+		}
+
+		for _, key := range builder.Base16Keys {
+			if _, err := builder.ParseHexColor(scheme.Palette[key]); err != nil {
+				t.Errorf("%s: %s = %q", test.format, key, scheme.Palette[key])
+			}
+		}
+	}
+}
+
+func TestImportSchemeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{name: "no background", format: "kitty", data: "foreground #ffffff\ncolor0 #000000\n"},
+		{name: "missing ansi", format: "kitty", data: "background #000000\nforeground #ffffff\ncolor0 #000000\n"},
+		{name: "bad colour", format: "kitty", data: "background #00000g\n"},
+		{name: "unknown format", format: "putty", data: ""},
+		{name: "undetected", format: "", data: "nothing to see"},
+		{name: "bad json", format: "gogh", data: "{"},
+	}
+
+	for _, test := range tests {
+		if _, err := ImportScheme("scheme", []byte(test.data), test.format); err == nil {
+			t.Errorf("%s: ImportScheme should fail", test.name)
+		}
+	}
+}
+
+func TestImportSchemeBrightFallback(t *testing.T) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "background %s\nforeground %s\n", importBackground, importForeground)
+	for i, c := range importANSI[:8] {
+		fmt.Fprintf(&b, "color%d %s\n", i, c)
+	}
+
+	if _, err := ImportScheme("scheme.conf", []byte(b.String()), ""); err != nil {
+		t.Errorf("ImportScheme without bright colours returned %v", err)
+	}
+}
+
+func TestParseTerminalColor(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{value: "#1d1f21", want: "#1d1f21", ok: true},
+		{value: "1d1f21", want: "#1d1f21", ok: true},
+		{value: "0x1D1F21", want: "#1d1f21", ok: true},
+		{value: `"#fff"`, want: "#ffffff", ok: true},
+		{value: " '#abc' ", want: "#aabbcc", ok: true},
+		{value: "rgb:1d/1f/21", want: "#1d1f21", ok: true},
+		{value: "rgb:ffff/8080/0000", want: "#ff8000", ok: true},
+		{value: "rgb:f/8/0", want: "#ff8800", ok: true},
+		{value: "rgb:1d/1f", ok: false},
+		{value: "red", ok: false},
+		{value: "", ok: false},
+	}
+
+	for _, test := range tests {
+		c, err := parseTerminalColor(test.value)
+		if (err == nil) != test.ok {
+			t.Errorf("parseTerminalColor(%q) error = %v, want ok %v", test.value, err, test.ok)
+			continue
+		}
+
+		if test.ok && builder.HexColor(c) != test.want {
+			t.Errorf("parseTerminalColor(%q) = %s, want %s", test.value, builder.HexColor(c), test.want)
+		}
+	}
+}
+
+func TestANSIIndex(t *testing.T) {
+	tests := map[string]int{
+		"color0":       0,
+		"color15":      15,
+		"color16":      -1,
+		"color_01":     0,
+		"color_16":     15,
+		"color_00":     -1,
+		"colorx":       -1,
+		"red":          1,
+		"brightblack":  8,
+		"brightwhite":  15,
+		"purple":       5,
+		"brightpurple": 13,
+		"orange":       -1,
+	}
+
+	for name, want := range tests {
+		if got := ansiIndex(name); got != want {
+			t.Errorf("ansiIndex(%q) = %d, want %d", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ClaraSmyth/pin/builder"
+	"gopkg.in/yaml.v3"
+)
+
+// The theme index caches the metadata of every scheme file so GetThemes only
+// has to parse the files that were added or changed since the last run.
+
+// themeIndexVersion is increased when entries gain fields so old entries are rebuilt
+const themeIndexVersion = 2
+
+type ThemeIndexEntry struct {
+	ModTime int64  `yaml:"modtime"`
+	Size    int64  `yaml:"size"`
+	Name    string `yaml:"name"`
+	Author  string `yaml:"author"`
+	Variant string `yaml:"variant"`
+	System  string `yaml:"system"`
+	Version int    `yaml:"version"`
+
+	// VariantInferred is set when the scheme has no variant and it was worked out from the palette
+	VariantInferred bool `yaml:"variantInferred,omitempty"`
+
+	// LowContrast lists the contrast pairs that fail the audit
+	LowContrast []string `yaml:"lowContrast,omitempty"`
+}
+
+type ThemeIndex struct {
+	Entries map[string]ThemeIndexEntry
+	changed bool
+}
+
+func ReadThemeIndex() *ThemeIndex {
+	index := &ThemeIndex{Entries: make(map[string]ThemeIndexEntry)}
+
+	data, err := os.ReadFile(config.Paths.ThemeIndex)
+	if err != nil {
+		return index
+	}
+
+	// A corrupt index is just rebuilt from the scheme files
+	err = yaml.Unmarshal(data, &index.Entries)
+	if err != nil || index.Entries == nil {
+		index.Entries = make(map[string]ThemeIndexEntry)
+		index.changed = true
+	}
+
+	return index
+}
+
+func (index *ThemeIndex) Lookup(path string, d fs.DirEntry) (ThemeIndexEntry, error) {
+	info, err := d.Info()
+	if err != nil {
+		return ThemeIndexEntry{}, err
+	}
+
+	entry, exists := index.Entries[path]
+	if exists && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size() && entry.Version == themeIndexVersion {
+		return entry, nil
+	}
+
+	data, err := ReadSchemeFile(path)
+	if err != nil {
+		return ThemeIndexEntry{}, err
+	}
+
+	scheme := builder.Scheme{}
+
+	// Unparsable schemes are still indexed so they can be listed and flagged on apply
+	_ = yaml.Unmarshal(data, &scheme)
+
+	entry = ThemeIndexEntry{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Name:    scheme.Name,
+		Author:  scheme.Author,
+		Variant: scheme.Variant,
+		System:  scheme.System,
+		Version: themeIndexVersion,
+	}
+
+	if entry.Variant == "" {
+		variant, err := builder.InferVariant(scheme)
+		if err == nil {
+			entry.Variant = variant
+			entry.VariantInferred = true
+		}
+	}
+
+	results, _ := builder.AuditScheme(scheme, 0)
+	for _, result := range results {
+		if !result.Pass() {
+			entry.LowContrast = append(entry.LowContrast, result.Name)
+		}
+	}
+
+	index.Entries[path] = entry
+	index.changed = true
+
+	return entry, nil
+}
+
+// Prune drops entries for scheme files that were not seen during the last walk.
+func (index *ThemeIndex) Prune(seen map[string]bool) {
+	for path := range index.Entries {
+		if !seen[path] {
+			delete(index.Entries, path)
+			index.changed = true
+		}
+	}
+}
+
+func (index *ThemeIndex) Write() error {
+	if !index.changed {
+		return nil
+	}
+
+	d, err := yaml.Marshal(&index.Entries)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(config.Paths.ThemeIndex), 0777)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(config.Paths.ThemeIndex, d, 0666)
+	if err != nil {
+		return err
+	}
+
+	index.changed = false
+
+	return nil
+}
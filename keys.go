@@ -15,6 +15,12 @@ type KeyMap struct {
 	Delete      key.Binding
 	Search      key.Binding
 	FetchThemes key.Binding
+	Favorite    key.Binding
+	Tags        key.Binding
+	Random      key.Binding
+	EditScheme  key.Binding
+	Invert      key.Binding
+	Watch       key.Binding
 	ToggleHelp  key.Binding
 }
 
@@ -31,6 +37,12 @@ var DefaultKeyMap = KeyMap{
 	Delete:      key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "delete")),
 	Search:      key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
 	FetchThemes: key.NewBinding(key.WithKeys("alt+p"), key.WithHelp("Alt+p", "fetch themes"), key.WithDisabled()),
+	Favorite:    key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "favorite"), key.WithDisabled()),
+	Tags:        key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "tags"), key.WithDisabled()),
+	Random:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "random"), key.WithDisabled()),
+	EditScheme:  key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "edit scheme"), key.WithDisabled()),
+	Invert:      key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "invert"), key.WithDisabled()),
+	Watch:       key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "watch")),
 	ToggleHelp:  key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
 }
 
@@ -56,5 +68,8 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		{k.NextPane, k.PrevPane},
 		{k.Search, k.ToggleHelp},
 		{k.Quit, k.FetchThemes},
+		{k.Favorite, k.Tags},
+		{k.Random, k.EditScheme},
+		{k.Invert, k.Watch},
 	}
 }
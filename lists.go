@@ -1,8 +1,10 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"slices"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -96,15 +98,47 @@ func (t TemplateDelegate) Render(w io.Writer, m list.Model, index int, item list
 // Theme List
 
 type Theme struct {
-	Name   string
-	Path   string
-	Hook   string
-	Active bool
-	Err    bool
+	Name      string
+	Path      string
+	Hook      string
+	Variant   string
+	Author    string
+	System    string
+	Slug      string
+	Source    string
+	Tags      []string
+	Favorite  bool
+	Recent    int
+	Active    bool
+	Duplicate bool
+	Err       bool
+
+	LowContrast     []string
+	VariantInferred bool
 }
 
 func (t Theme) FilterValue() string { return t.Name }
 
+func (t Theme) Key() ThemeKey { return ThemeKey{Source: t.Source, Slug: t.Slug} }
+
+// DisplayName includes the source when another theme has the same name.
+func (t Theme) DisplayName() string {
+	if t.Duplicate {
+		return t.Name + " (" + t.Source + ")"
+	}
+
+	return t.Name
+}
+
+// VariantLabel marks variants that were inferred from the palette.
+func (t Theme) VariantLabel() string {
+	if t.VariantInferred {
+		return t.Variant + " (inferred)"
+	}
+
+	return t.Variant
+}
+
 type ThemeDelegate struct{ styles ListStyles }
 
 func (t ThemeDelegate) Height() int                               { return 1 }
@@ -125,11 +159,52 @@ func (t ThemeDelegate) Render(w io.Writer, m list.Model, index int, item list.It
 		statusDot = "✗ "
 	}
 
+	name := theme.DisplayName()
+
+	if theme.Recent > 0 {
+		name = "↺ " + name
+	}
+
+	if theme.Favorite {
+		name = "★ " + name
+	}
+
+	if len(theme.LowContrast) > 0 {
+		name += " ◐"
+	}
+
+	variant := ""
+	if theme.Variant != "" {
+		variant = t.styles.StatusEmpty.Render(" " + theme.VariantLabel())
+	}
+
 	if index == m.Index() {
-		fmt.Fprint(w, t.styles.Selected.Render("❯ "+statusDot+theme.Name))
+		fmt.Fprint(w, t.styles.Selected.Render("❯ "+statusDot+name)+variant)
 		return
 	}
-	fmt.Fprint(w, t.styles.Unselected.Render("  "+statusDot+theme.Name))
+	fmt.Fprint(w, t.styles.Unselected.Render("  "+statusDot+name)+variant)
+}
+
+// SortRecentThemes moves recently applied themes to the top of the list, most recent first.
+func SortRecentThemes(items []list.Item) []list.Item {
+	sorted := slices.Clone(items)
+
+	slices.SortStableFunc(sorted, func(a, b list.Item) int {
+		recentA, recentB := a.(Theme).Recent, b.(Theme).Recent
+
+		switch {
+		case recentA == recentB:
+			return 0
+		case recentA == 0:
+			return 1
+		case recentB == 0:
+			return -1
+		default:
+			return cmp.Compare(recentA, recentB)
+		}
+	})
+
+	return sorted
 }
 
 func newLists(styles Styles) map[Pane]*list.Model {
@@ -152,10 +227,11 @@ func newLists(styles Styles) map[Pane]*list.Model {
 		templateList.SetItems(GetTemplates(selectedApp))
 	}
 
-	themeList := list.New(GetThemes(), ThemeDelegate{styles.BaseStyles}, 0, 0)
+	themeList := list.New(SortRecentThemes(GetThemes()), ThemeDelegate{styles.BaseStyles}, 0, 0)
 	themeList.Title = "Themes"
 	themeList.FilterInput.Prompt = "Find: "
-	themeList.FilterInput.CharLimit = 14
+	themeList.FilterInput.CharLimit = 64
+	themeList.Filter = ThemeFilter(&themeList)
 	themeList.SetShowHelp(false)
 	themeList.SetSpinner(spinner.MiniDot)
 	UpdateListStyles(&themeList, styles.BaseStyles)
@@ -11,24 +11,10 @@ func main() {
 	args := os.Args[1:]
 
 	if len(args) > 0 {
-		themes := GetThemes()
-
-		if len(themes) == 0 {
-			return
-		}
-
-		for _, v := range themes {
-			theme := v.(Theme)
-
-			if theme.Name == args[0] {
-				err := applyTheme(theme)
-
-				if err != nil {
-					fmt.Println("There was an error applying this theme!")
-				}
-
-				return
-			}
+		err := runCli(args)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
 		return
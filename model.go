@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/ClaraSmyth/pin/filepicker"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
@@ -27,6 +32,7 @@ const (
 	formActionCreate FormAction = iota
 	formActionEdit
 	formActionDelete
+	formActionTags
 )
 
 type Model struct {
@@ -43,6 +49,16 @@ type Model struct {
 	height           int
 	styles           Styles
 	fetchingThemes   bool
+	fetchCancel      context.CancelFunc
+	fetchProgress    chan string
+	fetchStatus      string
+	fetchResult      *fetchResultMsg
+
+	schemeEditor       SchemeEditor
+	schemeEditorActive bool
+
+	watchStop     chan struct{}
+	watchMessages chan string
 }
 
 type updateThemeListMsg []list.Item
@@ -56,6 +72,33 @@ type updateAppListMsg struct {
 
 type updateStylesMsg Styles
 
+type fetchProgressMsg string
+
+// watchMsg is a message from the watcher of messages.
+type watchMsg struct {
+	message  string
+	messages <-chan string
+}
+
+// watchStoppedMsg is sent when the watcher of messages has stopped.
+type watchStoppedMsg struct {
+	messages <-chan string
+}
+
+// applyResultMsg reports what happened to each app when a theme was applied,
+// themeListItems is set when applying failed so the theme can be marked.
+type applyResultMsg struct {
+	result         ApplyResult
+	themeListItems []list.Item
+}
+
+type fetchResultMsg struct {
+	themeListItems []list.Item
+	changes        []SourceChanges
+	err            error
+	status         string
+}
+
 func newModel() *Model {
 	colors := GetActiveColors()
 	styles := DefaultStyles(colors)
@@ -114,11 +157,21 @@ func (m *Model) updateStyles() tea.Cmd {
 
 func (m *Model) updateKeys() tea.Cmd {
 	m.keys.FetchThemes.SetEnabled(false)
+	m.keys.Favorite.SetEnabled(false)
+	m.keys.Tags.SetEnabled(false)
+	m.keys.Random.SetEnabled(false)
+	m.keys.EditScheme.SetEnabled(false)
+	m.keys.Invert.SetEnabled(false)
 	m.keys.Copy.SetEnabled(false)
 
 	switch m.pane {
 	case themePane:
 		m.keys.FetchThemes.SetEnabled(true)
+		m.keys.Favorite.SetEnabled(true)
+		m.keys.Tags.SetEnabled(true)
+		m.keys.Random.SetEnabled(true)
+		m.keys.EditScheme.SetEnabled(true)
+		m.keys.Invert.SetEnabled(true)
 	case templatePane:
 		m.keys.Copy.SetEnabled(true)
 	}
@@ -144,21 +197,55 @@ func (m *Model) selectItem() tea.Cmd {
 		return EditApp(newApp, app, m.lists[appPane].Items())
 
 	case Theme:
-		items := m.lists[themePane].Items()
-		for i, item := range items {
-			newItem := item.(Theme)
-			newItem.Active = false
-			if selectedItem.Name == newItem.Name {
-				newItem.Active = true
-			}
+		return m.applyTheme(selectedItem)
+	default:
+		return nil
+	}
+}
 
-			items[i] = newItem
+func (m *Model) applyTheme(theme Theme) tea.Cmd {
+	items := m.lists[themePane].Items()
+	for i, item := range items {
+		newItem := item.(Theme)
+		newItem.Active = false
+		if theme.Key() == newItem.Key() {
+			newItem.Active = true
 		}
 
-		return tea.Batch(ApplyThemeCmd(selectedItem, items), m.lists[themePane].SetItems(items))
-	default:
+		items[i] = newItem
+	}
+
+	return tea.Batch(ApplyThemeCmd(theme, items), m.lists[themePane].SetItems(items))
+}
+
+func (m *Model) triggerSchemeEditor() tea.Cmd {
+	theme, ok := m.lists[themePane].SelectedItem().(Theme)
+	if !ok {
+		return nil
+	}
+
+	editor, err := NewSchemeEditor(theme, m.styles)
+	if err != nil {
 		return nil
 	}
+
+	m.schemeEditor = editor
+	m.schemeEditorActive = true
+
+	return textinput.Blink
+}
+
+// previewScheme restyles the TUI with the palette being edited.
+func (m *Model) previewScheme() tea.Cmd {
+	colors, ok := m.schemeEditor.Colors()
+	if !ok {
+		return nil
+	}
+
+	m.styles = DefaultStyles(colors)
+	m.schemeEditor.SetStyles(m.styles)
+
+	return m.updateStyles()
 }
 
 func (m *Model) openFileEditor() tea.Cmd {
@@ -170,6 +257,10 @@ func (m *Model) openFileEditor() tea.Cmd {
 	case Template:
 		path = selectedItem.Path
 	case Theme:
+		// Bundled themes are read only
+		if selectedItem.Source == bundledSource {
+			return nil
+		}
 		path = selectedItem.Path
 	}
 
@@ -204,6 +295,7 @@ func (m *Model) triggerForm(formAction FormAction) tea.Cmd {
 	formEdit = false
 	formName = ""
 	formHook = ""
+	formTags = ""
 	formFilepicker = false
 	formRewrite = false
 	formApply = false
@@ -239,6 +331,10 @@ func (m *Model) triggerForm(formAction FormAction) tea.Cmd {
 			formName = item.Name
 			m.form = newForm(templateForm, m.lists[m.pane].Items(), m.styles.FormStyles)
 		case Theme:
+			if item.Source == bundledSource {
+				m.formActive = false
+				return nil
+			}
 			formEdit = true
 			formName = item.Name
 			formHook = item.Hook
@@ -249,7 +345,20 @@ func (m *Model) triggerForm(formAction FormAction) tea.Cmd {
 		}
 
 	case formActionDelete:
+		if theme, ok := m.lists[m.pane].SelectedItem().(Theme); ok && theme.Source == bundledSource {
+			m.formActive = false
+			return nil
+		}
 		m.form = deleteForm(m.styles.FormStyles)
+
+	case formActionTags:
+		theme, ok := m.lists[themePane].SelectedItem().(Theme)
+		if !ok {
+			m.formActive = false
+			return nil
+		}
+		formTags = strings.Join(theme.Tags, ", ")
+		m.form = tagsForm(m.styles.FormStyles)
 	}
 
 	return m.form.Init()
@@ -311,6 +420,9 @@ func (m *Model) handleFormSubmit() tea.Cmd {
 
 		case formActionDelete:
 			return DeleteTheme(m.lists[themePane].SelectedItem().(Theme))
+
+		case formActionTags:
+			return EditThemeTags(m.lists[themePane].SelectedItem().(Theme), parseTags(m.form.GetString("tags")))
 		}
 	}
 
@@ -330,9 +442,63 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case updateThemeListMsg:
+		return m, m.lists[themePane].SetItems(SortRecentThemes(msg))
+
+	case fetchProgressMsg:
+		m.fetchStatus = string(msg)
+		return m, waitForFetchProgress(m.fetchProgress)
+
+	case fetchResultMsg:
 		m.fetchingThemes = false
+		m.fetchCancel()
 		m.lists[themePane].StopSpinner()
-		return m, m.lists[themePane].SetItems(msg)
+
+		status := "Schemes up to date"
+		if slices.ContainsFunc(msg.changes, func(c SourceChanges) bool { return !c.Empty() }) {
+			status = "Schemes updated"
+		}
+
+		switch {
+		case errors.Is(msg.err, context.Canceled):
+			status = "Fetch cancelled"
+			msg.err = nil
+		case msg.err != nil:
+			status = "Fetch failed"
+		}
+
+		msg.status = status
+		m.fetchResult = &msg
+
+		return m, m.lists[themePane].SetItems(SortRecentThemes(msg.themeListItems))
+
+	case applyResultMsg:
+		status := msg.result.String()
+
+		if msg.themeListItems != nil {
+			if status == "" {
+				status = "There was an error applying this theme!"
+			}
+			return m, tea.Batch(m.lists[themePane].SetItems(msg.themeListItems), m.lists[appPane].NewStatusMessage(status))
+		}
+
+		if status == "" {
+			return m, UpdateActiveStyles
+		}
+
+		return m, tea.Batch(m.lists[appPane].NewStatusMessage(status), UpdateActiveStyles)
+
+	case watchStoppedMsg:
+		// Only reset when the watcher stopped by itself, e.g. after an error
+		if m.watchStop != nil && msg.messages == m.watchMessages {
+			m.watchStop = nil
+			m.watchMessages = nil
+			m.lists[appPane].Title = "Apps"
+		}
+		return m, nil
+
+	case watchMsg:
+		// The active scheme may have been re-applied
+		return m, tea.Batch(waitForWatchMessage(msg.messages), m.lists[appPane].NewStatusMessage(msg.message), UpdateActiveStyles)
 
 	case updateTemplateListMsg:
 		return m, m.lists[templatePane].SetItems(msg)
@@ -342,9 +508,37 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case updateStylesMsg:
 		m.styles = Styles(msg)
+		m.schemeEditor.SetStyles(m.styles)
 		return m, m.updateStyles()
 
 	case tea.KeyMsg:
+		// Any key dismisses the fetch changelog
+		if m.fetchResult != nil {
+			m.fetchResult = nil
+			return m, nil
+		}
+
+		if m.schemeEditorActive && !m.schemeEditor.Picking() {
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				m.schemeEditorActive = false
+				return m, UpdateActiveStyles
+
+			case key.Matches(msg, editorSave):
+				cmd := m.schemeEditor.Save()
+				if cmd == nil {
+					return m, nil
+				}
+				m.schemeEditorActive = false
+				return m, tea.Sequence(cmd, UpdateActiveStyles)
+			}
+		}
+
+		if m.schemeEditorActive {
+			m.schemeEditor, cmd = m.schemeEditor.Update(msg)
+			return m, tea.Batch(cmd, m.previewScheme())
+		}
+
 		if m.formActive {
 			switch {
 			case key.Matches(msg, m.keys.Back):
@@ -388,15 +582,49 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.lists[templatePane].SetHeight(m.height - lipgloss.Height(m.help.View(m.keys)))
 				m.lists[themePane].SetHeight(m.height - lipgloss.Height(m.help.View(m.keys)))
 
+			case key.Matches(msg, m.keys.Favorite):
+				if theme, ok := m.lists[themePane].SelectedItem().(Theme); ok {
+					return m, ToggleFavoriteTheme(theme)
+				}
+
+			case key.Matches(msg, m.keys.Tags):
+				return m, m.triggerForm(formActionTags)
+
+			case key.Matches(msg, m.keys.EditScheme):
+				return m, m.triggerSchemeEditor()
+
+			case key.Matches(msg, m.keys.Invert):
+				if theme, ok := m.lists[themePane].SelectedItem().(Theme); ok {
+					return m, InvertTheme(theme)
+				}
+
+			case key.Matches(msg, m.keys.Watch):
+				return m, m.toggleWatch()
+
+			case key.Matches(msg, m.keys.Random):
+				if theme, ok := RandomTheme(m.lists[themePane].VisibleItems()); ok {
+					return m, m.applyTheme(theme)
+				}
+
+			// Esc clears an applied filter before it cancels the fetch
+			case key.Matches(msg, m.keys.Back) && m.fetchingThemes && m.lists[m.pane].FilterState() == list.Unfiltered:
+				m.fetchCancel()
+				m.fetchStatus = "Cancelling"
+				return m, nil
+
 			case key.Matches(msg, m.keys.FetchThemes):
 				if !m.fetchingThemes {
-					m.fetchingThemes = true
-					return m, tea.Batch(GitCloneSchemes(), cmd, m.lists[themePane].StartSpinner())
+					return m, m.startFetch()
 				}
 			}
 		}
 	}
 
+	if m.schemeEditorActive {
+		m.schemeEditor, cmd = m.schemeEditor.Update(msg)
+		return m, cmd
+	}
+
 	if m.formActive {
 		if m.filepickerActive {
 			m.filepicker, cmd = m.filepicker.Update(msg)
@@ -454,7 +682,15 @@ func (m *Model) View() string {
 		titleStyles := m.styles.FocusedStyles.TitleBar
 		splitTitle := strings.Split(themeView, " ")
 		newTitle := lipgloss.JoinHorizontal(lipgloss.Left, splitTitle[1], lipgloss.PlaceHorizontal(15, lipgloss.Right, splitTitle[0]))
-		themeView = titleStyles.Render(newTitle)
+		themeView = lipgloss.JoinVertical(lipgloss.Top, titleStyles.Render(newTitle), m.styles.FocusedStyles.StatusBar.Copy().UnsetWidth().Render(m.fetchStatus))
+	}
+
+	if m.schemeEditorActive {
+		themeView = lipgloss.JoinVertical(lipgloss.Top, m.styles.FocusedStyles.TitleBar.Render("Edit Scheme"), "", m.schemeEditor.View())
+	}
+
+	if m.fetchResult != nil {
+		themeView = lipgloss.JoinVertical(lipgloss.Top, m.styles.FocusedStyles.TitleBar.Render("Fetched"), "", m.fetchResultView())
 	}
 
 	if m.formActive {
@@ -467,6 +703,8 @@ func (m *Model) View() string {
 			formTitleText = "Edit "
 		case formActionDelete:
 			formTitleText = "Delete "
+		case formActionTags:
+			formTitleText = "Tag "
 		}
 
 		formTitleStyles := m.styles.FocusedStyles.TitleBar
@@ -496,3 +734,92 @@ func (m *Model) View() string {
 		m.help.View(m.keys),
 	)
 }
+
+func (m *Model) startFetch() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.fetchingThemes = true
+	m.fetchCancel = cancel
+	m.fetchProgress = make(chan string, 16)
+	m.fetchStatus = "Fetching, esc to cancel"
+
+	return tea.Batch(
+		FetchSchemes(ctx, m.fetchProgress),
+		waitForFetchProgress(m.fetchProgress),
+		m.lists[themePane].StartSpinner(),
+	)
+}
+
+// toggleWatch starts or stops re-applying the active theme when templates,
+// custom schemes or apps.yaml change, like pin watch.
+func (m *Model) toggleWatch() tea.Cmd {
+	if m.watchStop != nil {
+		close(m.watchStop)
+		m.watchStop = nil
+		m.watchMessages = nil
+		m.lists[appPane].Title = "Apps"
+		return m.lists[appPane].NewStatusMessage("Stopped watching")
+	}
+
+	stop := make(chan struct{})
+	messages := make(chan string, 16)
+
+	go func() {
+		defer close(messages)
+
+		err := RunWatch(stop, func(message string) {
+			select {
+			case messages <- message:
+			default:
+			}
+		})
+		if err != nil {
+			messages <- "Stopped watching: " + err.Error()
+		}
+	}()
+
+	m.watchStop = stop
+	m.watchMessages = messages
+	m.lists[appPane].Title = "Apps (watching)"
+
+	return waitForWatchMessage(messages)
+}
+
+func waitForWatchMessage(messages <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		message, ok := <-messages
+		if !ok {
+			return watchStoppedMsg{messages: messages}
+		}
+
+		return watchMsg{message: message, messages: messages}
+	}
+}
+
+func (m *Model) fetchResultView() string {
+	lines := []string{m.styles.FocusedStyles.Selected.Render(m.fetchResult.status), ""}
+
+	if m.fetchResult.err != nil {
+		for _, line := range strings.Split(m.fetchResult.err.Error(), "\n") {
+			lines = append(lines, m.styles.FormStyles.Focused.ErrorMessage.Render("✗ "+line))
+		}
+	}
+
+	for _, changes := range m.fetchResult.changes {
+		if changes.Empty() {
+			continue
+		}
+
+		lines = append(lines, m.styles.FocusedStyles.Title.Render(" "+changes.Source+" "))
+		lines = append(lines, strings.Split(changes.String(), "\n")...)
+	}
+
+	// Leave room for the title and help
+	maxLines := max(m.height-lipgloss.Height(m.help.View(m.keys))-3, 1)
+	if len(lines) > maxLines {
+		hidden := len(lines) - maxLines + 1
+		lines = append(lines[:maxLines-1], fmt.Sprintf("… %d more", hidden))
+	}
+
+	return m.styles.FocusedStyles.Unselected.Width(25).Padding(0, 2).Render(strings.Join(lines, "\n"))
+}
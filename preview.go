@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"slices"
+	"strings"
+
+	"github.com/ClaraSmyth/pin/builder"
+	"github.com/gosimple/unidecode"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Previews are laid out once as a list of shapes and then drawn as SVG, or as
+// PNG with a built in bitmap font so no font files are needed.
+
+var previewFormats = []string{"svg", "png", "html"}
+
+const (
+	previewPadding = 24
+	previewSwatchW = 72
+	previewSwatchH = 48
+	previewGap     = 8
+	previewCharW   = 8
+	previewLineH   = 18
+	previewWidth   = 2*previewPadding + 8*previewSwatchW + 7*previewGap
+)
+
+type previewToken struct {
+	Text  string
+	Color string
+}
+
+// previewCode is a small Go snippet coloured with the base16 syntax roles.
+var previewCode = [][]previewToken{
+	{{"// Greet says hello to everyone in names", "base03"}},
+	{{"func ", "base0E"}, {"Greet", "base0D"}, {"(names []", "base05"}, {"string", "base0A"}, {") ", "base05"}, {"int", "base0A"}, {" {", "base05"}},
+	{{"\tcount ", "base08"}, {":= ", "base05"}, {"0", "base09"}},
+	{{"\tfor ", "base0E"}, {"_, name ", "base08"}, {":= ", "base05"}, {"range ", "base0E"}, {"names {", "base05"}},
+	{{"\t\tfmt.", "base05"}, {"Printf", "base0D"}, {"(", "base05"}, {"\"hello %s", "base0B"}, {"\\n", "base0C"}, {"\"", "base0B"}, {", name)", "base05"}},
+	{{"\t\tcount", "base08"}, {"++", "base05"}},
+	{{"\t}", "base05"}},
+	{{"\treturn ", "base0E"}, {"count", "base08"}, {" + ", "base05"}, {"true", "base09"}},
+	{{"}", "base05"}},
+}
+
+type previewShape struct {
+	X, Y, W, H int
+	Fill       color.RGBA
+	Stroke     *color.RGBA
+
+	// Text shapes are drawn at X, Y with the font size in H
+	Text string
+	Code bool
+}
+
+type previewCard struct {
+	Width, Height int
+	Shapes        []previewShape
+}
+
+func previewLayout(scheme builder.Scheme, code bool) (previewCard, error) {
+	colors := map[string]color.RGBA{}
+	for _, key := range builder.Base16Keys {
+		c, err := builder.ParseHexColor(scheme.Palette[key])
+		if err != nil {
+			return previewCard{}, err
+		}
+		colors[key] = c
+	}
+
+	border := colors["base02"]
+	shapes := []previewShape{
+		{Text: scheme.Name, X: previewPadding, Y: previewPadding + 16, H: 18, Fill: colors["base05"]},
+	}
+
+	subtitle := scheme.Variant
+	if scheme.Author != "" {
+		subtitle += " · " + scheme.Author
+	}
+	shapes = append(shapes, previewShape{Text: subtitle, X: previewPadding, Y: previewPadding + 36, H: 12, Fill: colors["base04"]})
+
+	y := previewPadding + 52
+
+	for row := 0; row < 2; row++ {
+		for i, key := range builder.Base16Keys[row*8 : row*8+8] {
+			x := previewPadding + i*(previewSwatchW+previewGap)
+
+			shapes = append(shapes,
+				previewShape{X: x, Y: y, W: previewSwatchW, H: previewSwatchH, Fill: colors[key], Stroke: &border},
+				previewShape{Text: key, X: x, Y: y + previewSwatchH + 14, H: 11, Fill: colors["base04"]},
+			)
+		}
+
+		y += previewSwatchH + 28
+	}
+
+	if code {
+		y += 4
+		panelH := len(previewCode)*previewLineH + 2*12
+
+		shapes = append(shapes, previewShape{X: previewPadding, Y: y, W: previewWidth - 2*previewPadding, H: panelH, Fill: colors["base01"]})
+
+		for i, line := range previewCode {
+			x := previewPadding + 16
+
+			for _, token := range line {
+				text := strings.ReplaceAll(token.Text, "\t", "    ")
+				indent := len(text) - len(strings.TrimLeft(text, " "))
+
+				shapes = append(shapes, previewShape{
+					Text: strings.TrimLeft(text, " "),
+					X:    x + indent*previewCharW,
+					Y:    y + 12 + i*previewLineH + 13,
+					H:    13,
+					Fill: colors[token.Color],
+					Code: true,
+				})
+
+				x += len([]rune(text)) * previewCharW
+			}
+		}
+
+		y += panelH + 8
+	}
+
+	height := y + previewPadding - 8
+	background := previewShape{W: previewWidth, H: height, Fill: colors["base00"]}
+
+	return previewCard{
+		Width:  previewWidth,
+		Height: height,
+		Shapes: slices.Insert(shapes, 0, background),
+	}, nil
+}
+
+func (card previewCard) SVG() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", card.Width, card.Height, card.Width, card.Height)
+
+	for _, shape := range card.Shapes {
+		fill := builder.HexColor(shape.Fill)
+
+		if shape.Text == "" {
+			stroke := ""
+			if shape.Stroke != nil {
+				stroke = fmt.Sprintf(` stroke="%s"`, builder.HexColor(*shape.Stroke))
+			}
+
+			fmt.Fprintf(&b, `  <rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="%s"%s/>`+"\n", shape.X, shape.Y, shape.W, shape.H, fill, stroke)
+			continue
+		}
+
+		font := "sans-serif"
+		if shape.Code {
+			font = "monospace"
+		}
+
+		fmt.Fprintf(&b, `  <text x="%d" y="%d" font-family="%s" font-size="%d" fill="%s" xml:space="preserve">%s</text>`+"\n", shape.X, shape.Y, font, shape.H, fill, html.EscapeString(shape.Text))
+	}
+
+	b.WriteString("</svg>")
+
+	return b.String()
+}
+
+func (card previewCard) PNG() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, card.Width, card.Height))
+
+	for _, shape := range card.Shapes {
+		if shape.Text != "" {
+			drawer := font.Drawer{
+				Dst:  img,
+				Src:  image.NewUniform(shape.Fill),
+				Face: basicfont.Face7x13,
+				Dot:  fixed.P(shape.X, shape.Y),
+			}
+
+			if !shape.Code {
+				drawer.DrawString(asciiText(shape.Text))
+				continue
+			}
+
+			// Code is laid out on a grid wider than the font so each glyph is
+			// placed on it to keep the tokens lined up
+			for i, r := range []rune(asciiText(shape.Text)) {
+				drawer.Dot = fixed.P(shape.X+i*previewCharW, shape.Y)
+				drawer.DrawString(string(r))
+			}
+
+			continue
+		}
+
+		rect := image.Rect(shape.X, shape.Y, shape.X+shape.W, shape.Y+shape.H)
+
+		if shape.Stroke != nil {
+			draw.Draw(img, rect, image.NewUniform(*shape.Stroke), image.Point{}, draw.Src)
+			rect = rect.Inset(1)
+		}
+
+		draw.Draw(img, rect, image.NewUniform(shape.Fill), image.Point{}, draw.Src)
+	}
+
+	var b bytes.Buffer
+	err := png.Encode(&b, img)
+
+	return b.Bytes(), err
+}
+
+// asciiText transliterates text for the bitmap font, which only has ASCII glyphs.
+func asciiText(text string) string {
+	return unidecode.Unidecode(strings.ReplaceAll(text, "·", "-"))
+}
+
+// RenderPreview draws a swatch card of the scheme, with a code snippet when code is set.
+func RenderPreview(scheme builder.Scheme, format string, code bool) ([]byte, error) {
+	if !slices.Contains(previewFormats, format) {
+		return nil, fmt.Errorf("Unknown preview format %q, expected one of %s", format, strings.Join(previewFormats, ", "))
+	}
+
+	card, err := previewLayout(scheme, code)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "png":
+		return card.PNG()
+	case "html":
+		return []byte(previewPage(scheme.Name, card.SVG())), nil
+	default:
+		return []byte(card.SVG()), nil
+	}
+}
+
+// RenderGallery renders an HTML page with a card for each theme. Themes whose
+// scheme can't be read are left out.
+func RenderGallery(themes []Theme, code bool) []byte {
+	cards := []string{}
+
+	for _, theme := range themes {
+		scheme, err := ReadThemeScheme(theme)
+		if err != nil {
+			continue
+		}
+
+		card, err := previewLayout(scheme, code)
+		if err != nil {
+			continue
+		}
+
+		cards = append(cards, fmt.Sprintf("<figure id=\"%s\">\n%s\n<figcaption>%s</figcaption>\n</figure>", html.EscapeString(theme.Key().String()), card.SVG(), html.EscapeString(theme.DisplayName())))
+	}
+
+	return []byte(previewPage("Pin themes", strings.Join(cards, "\n")))
+}
+
+func previewPage(title string, body string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { background: #1e1e1e; color: #ddd; font-family: sans-serif; display: flex; flex-wrap: wrap; gap: 24px; padding: 24px; }
+figure { margin: 0; }
+figcaption { padding-top: 8px; font-size: 14px; }
+</style>
+</head>
+<body>
+%s
+</body>
+</html>
+`, html.EscapeString(title), body)
+}
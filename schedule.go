@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const scheduleInterval = 30 * time.Second
+
+var errNoSun = errors.New("The sun does not rise or set")
+
+// ScheduleState records the last switch made by the scheduler so restarting it
+// does not undo a theme that was picked by hand since.
+type ScheduleState struct {
+	Theme string    `yaml:"theme"`
+	At    time.Time `yaml:"at"`
+}
+
+type scheduledSwitch struct {
+	Event ScheduleEvent
+	Time  time.Time
+}
+
+func (s Schedule) eventTime(event ScheduleEvent, day time.Time) (time.Time, error) {
+	at := strings.ToLower(strings.TrimSpace(event.At))
+
+	for _, sun := range []string{"sunrise", "sunset"} {
+		if !strings.HasPrefix(at, sun) {
+			continue
+		}
+
+		var offset time.Duration
+		if rest := strings.TrimPrefix(at, sun); rest != "" {
+			var err error
+			offset, err = time.ParseDuration(rest)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("Invalid offset in %q", event.At)
+			}
+		}
+
+		if s.Latitude == nil || s.Longitude == nil {
+			return time.Time{}, errors.New("Latitude and Longitude are required for sunrise and sunset")
+		}
+
+		sunrise, sunset, ok := sunTimes(day, *s.Latitude, *s.Longitude)
+		if !ok {
+			return time.Time{}, fmt.Errorf("%w on %s", errNoSun, day.Format(time.DateOnly))
+		}
+
+		if sun == "sunrise" {
+			return sunrise.Add(offset), nil
+		}
+		return sunset.Add(offset), nil
+	}
+
+	clock, err := time.Parse("15:04", at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time %q", event.At)
+	}
+
+	y, m, d := day.Date()
+	return time.Date(y, m, d, clock.Hour(), clock.Minute(), 0, 0, day.Location()), nil
+}
+
+// switches returns every scheduled switch from the day before until the day
+// after, in order. Sunrise and sunset events are skipped on days in polar day
+// or night.
+func (s Schedule) switches(now time.Time) ([]scheduledSwitch, error) {
+	switches := []scheduledSwitch{}
+
+	for _, offset := range []int{-1, 0, 1} {
+		day := now.AddDate(0, 0, offset)
+
+		for _, event := range s.Events {
+			t, err := s.eventTime(event, day)
+			if errors.Is(err, errNoSun) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			switches = append(switches, scheduledSwitch{Event: event, Time: t})
+		}
+	}
+
+	slices.SortFunc(switches, func(a, b scheduledSwitch) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return switches, nil
+}
+
+// Current returns the most recent switch at or before now.
+func (s Schedule) Current(now time.Time) (scheduledSwitch, error) {
+	switches, err := s.switches(now)
+	if err != nil {
+		return scheduledSwitch{}, err
+	}
+
+	current := scheduledSwitch{}
+	found := false
+
+	for _, sw := range switches {
+		if sw.Time.After(now) {
+			break
+		}
+		current = sw
+		found = true
+	}
+
+	if !found {
+		return scheduledSwitch{}, errors.New("No scheduled events")
+	}
+
+	return current, nil
+}
+
+func (s Schedule) Next(now time.Time) (scheduledSwitch, error) {
+	switches, err := s.switches(now)
+	if err != nil {
+		return scheduledSwitch{}, err
+	}
+
+	for _, sw := range switches {
+		if sw.Time.After(now) {
+			return sw, nil
+		}
+	}
+
+	return scheduledSwitch{}, errors.New("No scheduled events")
+}
+
+// SunWarning returns an error when sunrise and sunset events are skipped today.
+func (s Schedule) SunWarning(now time.Time) error {
+	for _, event := range s.Events {
+		if _, err := s.eventTime(event, now); errors.Is(err, errNoSun) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ReadScheduleState() ScheduleState {
+	state := ScheduleState{}
+
+	data, err := os.ReadFile(config.Paths.ScheduleState)
+	if err != nil {
+		return state
+	}
+
+	_ = yaml.Unmarshal(data, &state)
+
+	return state
+}
+
+func WriteScheduleState(state ScheduleState) error {
+	d, err := yaml.Marshal(&state)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(config.Paths.ScheduleState), 0777)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.Paths.ScheduleState, d, 0666)
+}
+
+// RunSchedule applies the scheduled themes until stop is closed. The schedule
+// is polled rather than slept on so switches still happen after a suspend.
+// Errors while polling are logged once and the scheduler keeps running, so
+// days without a sunrise or sunset fall back to the fixed time events.
+func RunSchedule(stop <-chan struct{}, log func(string)) error {
+	if len(config.Schedule.Events) == 0 {
+		return errors.New("No schedule events configured")
+	}
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	lastErr := ""
+	logErr := func(err error) {
+		message := ""
+		if err != nil {
+			message = err.Error()
+		}
+		if message != "" && message != lastErr {
+			log(message)
+		}
+		lastErr = message
+	}
+
+	for {
+		now := time.Now()
+
+		current, err := config.Schedule.Current(now)
+		if err == nil {
+			err = config.Schedule.SunWarning(now)
+		}
+		logErr(err)
+
+		state := ReadScheduleState()
+
+		if !current.Time.IsZero() && !state.At.Equal(current.Time) {
+			theme, found := FindTheme(current.Event.Theme)
+
+			if !found {
+				log(fmt.Sprintf("Theme %q not found", current.Event.Theme))
+			} else if result, err := applyTheme(theme); err != nil {
+				log(fmt.Sprintf("There was an error applying %s", theme.Name))
+			} else {
+				log(applyLogMessage(fmt.Sprintf("Applied %s (%s)", theme.Name, current.Event.At), result))
+			}
+
+			// Failed switches are recorded too so they are not retried every tick
+			err = WriteScheduleState(ScheduleState{Theme: current.Event.Theme, At: current.Time})
+			if err != nil {
+				log(err.Error())
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sunTimes calculates sunrise and sunset for the given day using the sunrise
+// equation. It returns false during polar day or night.
+func sunTimes(day time.Time, latitude, longitude float64) (time.Time, time.Time, bool) {
+	rad := math.Pi / 180
+
+	y, m, d := day.Date()
+	noon := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	julianDay := float64(noon.Unix())/86400 + 2440587.5
+
+	// Mean solar time, solar mean anomaly and equation of the center
+	n := math.Round(julianDay - 2451545.0 + 0.0008)
+	meanSolarTime := n - longitude/360
+	meanAnomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	center := 1.9148*math.Sin(meanAnomaly*rad) + 0.02*math.Sin(2*meanAnomaly*rad) + 0.0003*math.Sin(3*meanAnomaly*rad)
+
+	eclipticLongitude := math.Mod(meanAnomaly+center+180+102.9372, 360)
+	transit := 2451545.0 + meanSolarTime + 0.0053*math.Sin(meanAnomaly*rad) - 0.0069*math.Sin(2*eclipticLongitude*rad)
+
+	sinDeclination := math.Sin(eclipticLongitude*rad) * math.Sin(23.4397*rad)
+	cosDeclination := math.Cos(math.Asin(sinDeclination))
+
+	cosHourAngle := (math.Sin(-0.833*rad) - math.Sin(latitude*rad)*sinDeclination) / (math.Cos(latitude*rad) * cosDeclination)
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	hourAngle := math.Acos(cosHourAngle) / rad
+
+	toTime := func(julian float64) time.Time {
+		unix := (julian - 2440587.5) * 86400
+		return time.Unix(int64(unix), 0).In(day.Location())
+	}
+
+	return toTime(transit - hourAngle/360), toTime(transit + hourAngle/360), true
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSunTimes(t *testing.T) {
+	tests := []struct {
+		name                string
+		day                 string
+		latitude, longitude float64
+		sunrise, sunset     string
+	}{
+		// Published sunrise and sunset times in UTC
+		{name: "london summer", day: "2024-06-21", latitude: 51.5074, longitude: -0.1278, sunrise: "03:43", sunset: "20:21"},
+		{name: "london winter", day: "2024-12-21", latitude: 51.5074, longitude: -0.1278, sunrise: "08:03", sunset: "15:53"},
+		{name: "sydney", day: "2024-06-21", latitude: -33.8688, longitude: 151.2093, sunrise: "20:59", sunset: "06:53"},
+		{name: "quito", day: "2024-03-20", latitude: -0.1807, longitude: -78.4678, sunrise: "11:13", sunset: "23:20"},
+	}
+
+	const tolerance = 5 * time.Minute
+
+	// Times are compared on the clock as some fall on the day before in UTC
+	near := func(got time.Time, want string) bool {
+		clock, _ := time.Parse("15:04", want)
+		diff := time.Duration(got.Hour()*60+got.Minute()-clock.Hour()*60-clock.Minute()) * time.Minute
+		diff = (diff%(24*time.Hour)+36*time.Hour)%(24*time.Hour) - 12*time.Hour
+		return diff.Abs() <= tolerance
+	}
+
+	for _, test := range tests {
+		day, _ := time.ParseInLocation(time.DateOnly, test.day, time.UTC)
+
+		sunrise, sunset, ok := sunTimes(day, test.latitude, test.longitude)
+		if !ok {
+			t.Errorf("%s: sunTimes found no sunrise or sunset", test.name)
+			continue
+		}
+
+		if !near(sunrise, test.sunrise) {
+			t.Errorf("%s: sunrise = %s, want about %s", test.name, sunrise.Format("15:04"), test.sunrise)
+		}
+
+		if !near(sunset, test.sunset) {
+			t.Errorf("%s: sunset = %s, want about %s", test.name, sunset.Format("15:04"), test.sunset)
+		}
+	}
+}
+
+func TestSunTimesPolar(t *testing.T) {
+	for _, day := range []string{"2024-06-21", "2024-12-21"} {
+		d, _ := time.ParseInLocation(time.DateOnly, day, time.UTC)
+
+		if _, _, ok := sunTimes(d, 78.2232, 15.6267); ok {
+			t.Errorf("sunTimes in Svalbard on %s should be polar day or night", day)
+		}
+	}
+}
+
+func scheduleAt(t *testing.T, s string) time.Time {
+	t.Helper()
+
+	at, err := time.ParseInLocation(time.DateTime, s, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return at
+}
+
+func TestScheduleCurrent(t *testing.T) {
+	schedule := Schedule{Events: []ScheduleEvent{
+		{At: "07:00", Theme: "light"},
+		{At: "19:30", Theme: "dark"},
+	}}
+
+	tests := []struct {
+		now   string
+		theme string
+		at    string
+	}{
+		{now: "2024-05-02 12:00:00", theme: "light", at: "2024-05-02 07:00:00"},
+		{now: "2024-05-02 07:00:00", theme: "light", at: "2024-05-02 07:00:00"},
+		{now: "2024-05-02 20:00:00", theme: "dark", at: "2024-05-02 19:30:00"},
+		{now: "2024-05-02 03:00:00", theme: "dark", at: "2024-05-01 19:30:00"},
+	}
+
+	for _, test := range tests {
+		current, err := schedule.Current(scheduleAt(t, test.now))
+		if err != nil {
+			t.Errorf("Current(%s) returned %v", test.now, err)
+			continue
+		}
+
+		if current.Event.Theme != test.theme || !current.Time.Equal(scheduleAt(t, test.at)) {
+			t.Errorf("Current(%s) = %s at %s, want %s at %s", test.now, current.Event.Theme, current.Time, test.theme, test.at)
+		}
+	}
+
+	next, err := schedule.Next(scheduleAt(t, "2024-05-02 20:00:00"))
+	if err != nil || next.Event.Theme != "light" || !next.Time.Equal(scheduleAt(t, "2024-05-03 07:00:00")) {
+		t.Errorf("Next = %s at %s, %v, want light the next morning", next.Event.Theme, next.Time, err)
+	}
+}
+
+func TestScheduleCurrentPolar(t *testing.T) {
+	latitude, longitude := 78.2232, 15.6267
+
+	schedule := Schedule{
+		Latitude:  &latitude,
+		Longitude: &longitude,
+		Events: []ScheduleEvent{
+			{At: "sunrise", Theme: "light"},
+			{At: "sunset", Theme: "dark"},
+			{At: "22:00", Theme: "night"},
+		},
+	}
+
+	now := scheduleAt(t, "2024-06-21 12:00:00")
+
+	// Sun events are skipped and the fixed time event is used instead
+	current, err := schedule.Current(now)
+	if err != nil {
+		t.Fatalf("Current in polar day returned %v", err)
+	}
+
+	if current.Event.Theme != "night" {
+		t.Errorf("Current in polar day = %s, want night", current.Event.Theme)
+	}
+
+	if schedule.SunWarning(now) == nil {
+		t.Error("SunWarning in polar day should report the skipped events")
+	}
+
+	schedule.Events = schedule.Events[:2]
+
+	if _, err := schedule.Current(now); err == nil {
+		t.Error("Current with only sun events in polar day should have no events")
+	}
+}
+
+func TestScheduleErrors(t *testing.T) {
+	now := scheduleAt(t, "2024-05-02 12:00:00")
+
+	tests := []Schedule{
+		{Events: []ScheduleEvent{{At: "sunset", Theme: "dark"}}},
+		{Events: []ScheduleEvent{{At: "25:00", Theme: "dark"}}},
+		{Events: []ScheduleEvent{{At: "sunset+an hour", Theme: "dark"}}},
+	}
+
+	for _, schedule := range tests {
+		if _, err := schedule.Current(now); err == nil {
+			t.Errorf("Current with %q should fail", schedule.Events[0].At)
+		}
+	}
+}
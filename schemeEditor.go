@@ -0,0 +1,344 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ClaraSmyth/pin/builder"
+	"github.com/ClaraSmyth/pin/colorpicker"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/gosimple/slug"
+	"gopkg.in/yaml.v3"
+)
+
+// The scheme editor edits the metadata and palette of a scheme in the Themes
+// pane. Fields are the name, author and variant followed by base00 to base0F,
+// and base10 to base17 for base24 schemes.
+// Pressing enter on a palette slot opens a colour picker for it.
+
+const (
+	editorName = iota
+	editorAuthor
+	editorVariant
+	editorPalette
+)
+
+var editorLabels = []string{"Name", "Author", "Variant"}
+
+var (
+	editorNext   = key.NewBinding(key.WithKeys("tab", "down", "enter"))
+	editorPrev   = key.NewBinding(key.WithKeys("shift+tab", "up"))
+	editorSave   = key.NewBinding(key.WithKeys("ctrl+s"))
+	editorPick   = key.NewBinding(key.WithKeys("enter"))
+	editorCancel = key.NewBinding(key.WithKeys("esc"))
+)
+
+type SchemeEditor struct {
+	theme  Theme
+	scheme builder.Scheme
+	keys   []string
+	inputs []textinput.Model
+	focus  int
+	err    error
+	styles *huh.Theme
+
+	picker       colorpicker.Model
+	picking      bool
+	pickerStyles colorpicker.Styles
+}
+
+func NewSchemeEditor(theme Theme, styles Styles) (SchemeEditor, error) {
+	data, err := ReadSchemeFile(theme.Path)
+	if err != nil {
+		return SchemeEditor{}, err
+	}
+
+	scheme := builder.Scheme{}
+
+	err = yaml.Unmarshal(data, &scheme)
+	if err != nil {
+		return SchemeEditor{}, err
+	}
+
+	if scheme.System == "" {
+		scheme.System = "base16"
+	}
+
+	e := SchemeEditor{theme: theme, scheme: scheme, keys: builder.Base16Keys}
+	if scheme.System == "base24" {
+		e.keys = append(slices.Clone(builder.Base16Keys), builder.Base24Keys...)
+	}
+
+	e.inputs = append(e.inputs,
+		newEditorInput(scheme.Name, 64, 23),
+		newEditorInput(scheme.Author, 128, 23),
+		newEditorInput(scheme.Variant, 5, 23),
+	)
+
+	for _, key := range e.keys {
+		e.inputs = append(e.inputs, newEditorInput(strings.TrimPrefix(scheme.Palette[key], "#"), 7, 6))
+	}
+
+	if scheme.Variant == "" {
+		if variant, err := builder.InferVariant(scheme); err == nil {
+			e.inputs[editorVariant].Placeholder = variant + " (inferred)"
+		}
+	}
+
+	e.inputs[editorName].Focus()
+	e.SetStyles(styles)
+
+	return e, nil
+}
+
+func newEditorInput(value string, charLimit int, width int) textinput.Model {
+	input := textinput.New()
+	input.Prompt = ""
+	input.CharLimit = charLimit
+	input.Width = width
+	input.SetValue(value)
+	input.CursorEnd()
+	return input
+}
+
+func (e *SchemeEditor) SetStyles(styles Styles) {
+	e.styles = styles.FormStyles
+	e.pickerStyles = colorpicker.Styles(styles.ColorPickerStyles)
+	e.picker.Styles = e.pickerStyles
+
+	for i := range e.inputs {
+		e.inputs[i].TextStyle = e.styles.Focused.TextInput.Text
+		e.inputs[i].Cursor.Style = e.styles.Focused.TextInput.Cursor
+		e.inputs[i].PlaceholderStyle = e.styles.Focused.TextInput.Placeholder
+	}
+}
+
+// Picking reports whether the colour picker is open.
+func (e SchemeEditor) Picking() bool {
+	return e.picking
+}
+
+func (e *SchemeEditor) openPicker() {
+	c, err := builder.ParseHexColor(e.inputs[e.focus].Value())
+	if err != nil {
+		c.A = 255
+	}
+
+	e.picker = colorpicker.New(c)
+	e.picker.Styles = e.pickerStyles
+	e.picking = true
+}
+
+// updatePicker edits the focused palette slot with the picker, enter keeps the
+// colour and esc restores the original.
+func (e SchemeEditor) updatePicker(msg tea.Msg) (SchemeEditor, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, editorPick):
+			e.picking = false
+			return e, nil
+		case key.Matches(msg, editorCancel):
+			e.picking = false
+			e.inputs[e.focus].SetValue(strings.TrimPrefix(builder.HexColor(e.picker.Original()), "#"))
+			return e, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	e.picker, cmd = e.picker.Update(msg)
+	e.inputs[e.focus].SetValue(strings.TrimPrefix(e.picker.Value(), "#"))
+
+	return e, cmd
+}
+
+func (e SchemeEditor) Update(msg tea.Msg) (SchemeEditor, tea.Cmd) {
+	if e.picking {
+		return e.updatePicker(msg)
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, editorPick) && e.focus >= editorPalette:
+			e.openPicker()
+			return e, nil
+		case key.Matches(msg, editorNext):
+			return e, e.setFocus((e.focus + 1) % len(e.inputs))
+		case key.Matches(msg, editorPrev):
+			return e, e.setFocus((e.focus + len(e.inputs) - 1) % len(e.inputs))
+		}
+	}
+
+	var cmd tea.Cmd
+	e.inputs[e.focus], cmd = e.inputs[e.focus].Update(msg)
+	e.err = nil
+
+	return e, cmd
+}
+
+func (e *SchemeEditor) setFocus(focus int) tea.Cmd {
+	e.inputs[e.focus].Blur()
+	e.focus = focus
+	return e.inputs[e.focus].Focus()
+}
+
+func (e SchemeEditor) fieldErr(field int) error {
+	value := strings.TrimSpace(e.inputs[field].Value())
+
+	switch field {
+	case editorName:
+		if value == "" || slug.Make(value) == "" {
+			return errors.New("Name cant be empty!")
+		}
+	case editorVariant:
+		if value != "" && value != "dark" && value != "light" {
+			return errors.New("Variant must be dark or light!")
+		}
+	case editorAuthor:
+	default:
+		_, err := builder.ParseHexColor(value)
+		if err != nil {
+			return fmt.Errorf("Invalid hex for %s!", e.keys[field-editorPalette])
+		}
+	}
+
+	return nil
+}
+
+// Palette is the palette of the scheme with the edited slots applied, keys the
+// editor does not show are kept as they are.
+func (e SchemeEditor) Palette() map[string]string {
+	palette := maps.Clone(e.scheme.Palette)
+	if palette == nil {
+		palette = make(map[string]string)
+	}
+
+	for i, key := range e.keys {
+		palette[key] = strings.TrimSpace(e.inputs[editorPalette+i].Value())
+	}
+
+	return palette
+}
+
+func (e SchemeEditor) Scheme() (builder.Scheme, error) {
+	for i := range e.inputs {
+		if err := e.fieldErr(i); err != nil {
+			return builder.Scheme{}, err
+		}
+	}
+
+	scheme := e.scheme
+	scheme.Name = strings.TrimSpace(e.inputs[editorName].Value())
+	scheme.Author = strings.TrimSpace(e.inputs[editorAuthor].Value())
+	scheme.Variant = strings.TrimSpace(e.inputs[editorVariant].Value())
+	scheme.Palette = e.Palette()
+
+	for _, key := range e.keys {
+		c, _ := builder.ParseHexColor(scheme.Palette[key])
+		scheme.Palette[key] = builder.HexColor(c)
+	}
+
+	return scheme, nil
+}
+
+// Path is where the scheme is saved. Custom themes are edited in place, other
+// themes are read only so a custom copy is made instead.
+func (e SchemeEditor) Path() string {
+	if e.theme.Source == customSource {
+		return e.theme.Path
+	}
+
+	name := slug.Make(strings.TrimSpace(e.inputs[editorName].Value()))
+	return filepath.Join(config.Paths.CustomSchemes, name+".yaml")
+}
+
+func (e *SchemeEditor) Save() tea.Cmd {
+	scheme, err := e.Scheme()
+	if err != nil {
+		e.err = err
+		return nil
+	}
+
+	path := e.Path()
+
+	if _, err := os.Stat(path); path != e.theme.Path && err == nil {
+		e.err = errors.New("Already Exists!")
+		return nil
+	}
+
+	return SaveScheme(path, scheme)
+}
+
+// Colors returns the palette being edited, or false while it is invalid.
+func (e SchemeEditor) Colors() (Colors, bool) {
+	colors, err := PaletteColors(e.Palette())
+	return colors, err == nil
+}
+
+func (e SchemeEditor) label(field int, text string) string {
+	if field == e.focus {
+		return e.styles.Focused.Title.Render(text)
+	}
+
+	return e.styles.Blurred.Title.Render(text)
+}
+
+func (e SchemeEditor) cell(field int) string {
+	key := e.keys[field-editorPalette]
+	swatch := "  "
+
+	if c, err := builder.ParseHexColor(e.inputs[field].Value()); err == nil {
+		hex := builder.HexColor(c)
+		swatch = lipgloss.NewStyle().Foreground(lipgloss.Color(hex)).Render("██")
+	}
+
+	return e.label(field, strings.TrimPrefix(key, "base")) + " " + swatch + " " + e.inputs[field].View()
+}
+
+func (e SchemeEditor) View() string {
+	lines := []string{}
+
+	for field, text := range editorLabels {
+		lines = append(lines, e.label(field, text), e.inputs[field].View())
+	}
+
+	lines = append(lines, "")
+
+	if e.picking {
+		key := e.keys[e.focus-editorPalette]
+		lines = append(lines, e.styles.Focused.Title.Render(key), e.picker.View(), "")
+		lines = append(lines, e.styles.Focused.Description.Render("enter keep • esc back"))
+		return strings.Join(lines, "\n")
+	}
+
+	half := len(e.keys) / 2
+	for row := 0; row < half; row++ {
+		left := e.cell(editorPalette + row)
+		right := e.cell(editorPalette + half + row)
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, left, right))
+	}
+
+	lines = append(lines, "")
+
+	err := e.err
+	if err == nil {
+		err = e.fieldErr(e.focus)
+	}
+
+	footer := e.styles.Focused.Description.Render("enter pick • ctrl+s save • esc cancel")
+	if err != nil {
+		footer = e.styles.Focused.ErrorMessage.Render(err.Error())
+	}
+
+	lines = append(lines, lipgloss.NewStyle().Width(25).Render(footer))
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ClaraSmyth/pin/builder"
+)
+
+// Shell init scripts set the terminal palette when a shell starts. They are
+// cached in the data directory and rewritten whenever a theme is applied, so
+// starting a shell never has to read the scheme.
+
+var shellFormats = []string{"bash", "zsh", "fish"}
+
+func shellInitPath(format string) string {
+	return filepath.Join(config.Paths.ShellInit, "init."+format)
+}
+
+// ShellInitScript returns a script for the shell that prints the escape
+// sequences of the scheme when the shell is run in a terminal.
+func ShellInitScript(scheme builder.Scheme, format string) (string, error) {
+	sequences, err := TerminalSequences(scheme)
+	if err != nil {
+		return "", err
+	}
+
+	// Escape the sequences for a printf format string in single quotes
+	printf := strings.NewReplacer(`\`, `\\`, "%", "%%", "\033", `\033`).Replace(sequences)
+
+	comment := fmt.Sprintf("# %s, generated by pin", singleLine(scheme.Name))
+
+	switch format {
+	case "bash", "zsh":
+		return fmt.Sprintf("%s\nif [ -t 1 ]; then\n  printf '%s'\nfi\n", comment, printf), nil
+	case "fish":
+		// Backslashes are also escapes in fish single quoted strings
+		printf = strings.ReplaceAll(printf, `\\`, `\\\\`)
+		return fmt.Sprintf("%s\nif isatty stdout\n  printf '%s'\nend\n", comment, printf), nil
+	default:
+		return "", fmt.Errorf("Unknown shell %q, expected one of %s", format, strings.Join(shellFormats, ", "))
+	}
+}
+
+// WriteShellInit caches the init script of every shell for the scheme.
+func WriteShellInit(scheme builder.Scheme) error {
+	err := os.MkdirAll(config.Paths.ShellInit, 0777)
+	if err != nil {
+		return err
+	}
+
+	for _, format := range shellFormats {
+		script, err := ShellInitScript(scheme, format)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(shellInitPath(format), []byte(script), 0666)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateShellInit refreshes the cached init scripts when they have been
+// created by pin shell-init before.
+func updateShellInit(scheme builder.Scheme) {
+	if _, err := os.Stat(config.Paths.ShellInit); err != nil {
+		return
+	}
+
+	_ = WriteShellInit(scheme)
+}
+
+// ReadShellInit returns the cached init script for the shell, creating the
+// cache from the active theme if it doesn't exist yet.
+func ReadShellInit(format string) (string, error) {
+	script, err := os.ReadFile(shellInitPath(format))
+	if err == nil {
+		return string(script), nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	theme, found := FindTheme(ReadState().ActiveTheme.String())
+	if !found {
+		return "", errors.New("No theme has been applied yet")
+	}
+
+	scheme, err := ReadThemeScheme(theme)
+	if err != nil {
+		return "", err
+	}
+
+	err = WriteShellInit(scheme)
+	if err != nil {
+		return "", err
+	}
+
+	return ShellInitScript(scheme, format)
+}
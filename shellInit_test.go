@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fishUnquote undoes the escapes fish applies inside single quotes
+func fishUnquote(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\'`, `'`).Replace(s)
+}
+
+func printfArgument(t *testing.T, script string) string {
+	t.Helper()
+
+	start := strings.Index(script, "printf '")
+	end := strings.LastIndex(script, "'")
+	if start == -1 || end <= start+len("printf '") {
+		t.Fatalf("no printf in script:\n%s", script)
+	}
+
+	argument := script[start+len("printf '") : end]
+	if strings.Contains(argument, "'") {
+		t.Fatalf("single quote inside the printf argument:\n%s", script)
+	}
+
+	return argument
+}
+
+func TestShellInitScript(t *testing.T) {
+	scheme := exportTestScheme("Two\nlines 'quoted' 100%", "Author")
+
+	sequences, err := TerminalSequences(scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range shellFormats {
+		script, err := ShellInitScript(scheme, format)
+		if err != nil {
+			t.Errorf("%s: ShellInitScript returned %v", format, err)
+			continue
+		}
+
+		if !strings.HasPrefix(script, "# Two lines 'quoted' 100%, generated by pin\n") {
+			t.Errorf("%s: the name is not a single line comment:\n%s", format, script)
+		}
+
+		if strings.Contains(script, "\033") {
+			t.Errorf("%s: script contains a raw escape character", format)
+		}
+
+		argument := printfArgument(t, script)
+		if format == "fish" {
+			argument = fishUnquote(argument)
+		}
+
+		// The shells share printf, so bash stands in for all of them
+		out, err := exec.Command("bash", "-c", `printf "$1"`, "bash", argument).Output()
+		if err != nil {
+			t.Skipf("bash is not available: %v", err)
+		}
+
+		if string(out) != sequences {
+			t.Errorf("%s: printf prints %q, want %q", format, out, sequences)
+		}
+	}
+
+	if _, err := ShellInitScript(scheme, "powershell"); err == nil {
+		t.Error("ShellInitScript with an unknown shell should fail")
+	}
+}
+
+func TestShellInitScriptTerminalOnly(t *testing.T) {
+	script, err := ShellInitScript(exportTestScheme("Test", "Author"), "bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Output from the test is not a terminal, so nothing is printed
+	out, err := exec.Command("bash", "-c", script).Output()
+	if err != nil {
+		t.Skipf("bash is not available: %v", err)
+	}
+
+	if len(out) != 0 {
+		t.Errorf("script printed %q when stdout is not a terminal", out)
+	}
+}
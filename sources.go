@@ -0,0 +1,687 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const customSource = "custom"
+
+// A Source is a place schemes are fetched from. Exactly one of Git, Dir or
+// Tarball should be set. Git sources are downloaded as an archive of the ref
+// unless FetchWithGit is set, Archive overrides the archive url for hosts pin
+// doesn't know.
+type Source struct {
+	Name    string `yaml:"Name"`
+	Git     string `yaml:"Git"`
+	Ref     string `yaml:"Ref"`
+	Archive string `yaml:"Archive"`
+	Dir     string `yaml:"Dir"`
+	Tarball string `yaml:"Tarball"`
+	Subdir  string `yaml:"Subdir"`
+}
+
+// archiveETagFile stores the ETag of the last downloaded archive so unchanged
+// archives are not downloaded again.
+const archiveETagFile = ".pin-etag"
+
+var defaultSources = []Source{
+	{Name: "tinted-theming", Git: "https://github.com/tinted-theming/schemes.git", Subdir: "base16"},
+}
+
+func (s Source) Validate() error {
+	set := 0
+	for _, v := range []string{s.Git, s.Dir, s.Tarball} {
+		if v != "" {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("Source %q must set exactly one of Git, Dir or Tarball", s.Name)
+	}
+
+	if s.Archive != "" && s.Git == "" {
+		return fmt.Errorf("Source %q can only set Archive with Git", s.Name)
+	}
+
+	if s.Name == "" || s.Name == customSource || s.Name == bundledSource || !validateFilename(s.Name) {
+		return fmt.Errorf("Invalid source name %q", s.Name)
+	}
+
+	return nil
+}
+
+// ValidateSources validates each source and that no two sources share a name,
+// as sources with the same name would be fetched into the same directory.
+func ValidateSources(sources []Source) error {
+	names := make(map[string]bool)
+
+	for _, source := range sources {
+		err := source.Validate()
+		if err != nil {
+			return err
+		}
+
+		// Names are compared ignoring case for case insensitive filesystems
+		name := strings.ToLower(source.Name)
+		if names[name] {
+			return fmt.Errorf("Source name %q is used more than once", source.Name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+// Path is the directory the source is fetched into, or the configured directory for Dir sources.
+func (s Source) Path() string {
+	if s.Dir != "" {
+		return expandHome(s.Dir)
+	}
+
+	return filepath.Join(config.Paths.BaseSchemes, s.Name)
+}
+
+// SchemesPath is the directory walked for scheme files.
+func (s Source) SchemesPath() string {
+	return filepath.Join(s.Path(), s.Subdir)
+}
+
+// Fetch updates the source and returns what changed. The existing schemes are
+// left untouched unless the update succeeds and contains schemes. Progress
+// lines are passed to progress as the fetch runs.
+func (s Source) Fetch(ctx context.Context, progress func(string)) (SourceChanges, error) {
+	changes := SourceChanges{Source: s.Name}
+
+	if s.Dir != "" {
+		return changes, nil
+	}
+
+	before, err := snapshotSchemes(s.SchemesPath())
+	if err != nil {
+		return changes, err
+	}
+
+	switch {
+	case s.Git != "" && config.FetchWithGit:
+		err = s.fetchGit(ctx, progress)
+	case s.Git != "":
+		err = s.fetchGitArchive(ctx, progress)
+	default:
+		err = s.fetchTarball(ctx, progress)
+	}
+	if err != nil {
+		return changes, err
+	}
+
+	after, err := snapshotSchemes(s.SchemesPath())
+	if err != nil {
+		return changes, err
+	}
+
+	return diffSchemes(s.Name, before, after), nil
+}
+
+func (s Source) fetchGit(ctx context.Context, progress func(string)) error {
+	target := s.Path()
+
+	_, err := os.Stat(filepath.Join(target, ".git"))
+	if err == nil {
+		return s.updateGit(ctx, target, progress)
+	}
+
+	return replaceDir(target, s.Subdir, func(tmp string) error {
+		args := []string{"clone", "--progress", "--depth", "1"}
+		if s.Ref != "" {
+			args = append(args, "--branch", s.Ref)
+		}
+		args = append(args, s.Git, tmp)
+
+		return runGit(ctx, "", progress, args...)
+	})
+}
+
+// updateGit fetches the ref into an existing checkout. The working tree is only
+// reset once the fetch succeeds, and is restored if the new tree has no schemes.
+func (s Source) updateGit(ctx context.Context, target string, progress func(string)) error {
+	ref := s.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	err := runGit(ctx, target, nil, "remote", "set-url", "origin", s.Git)
+	if err != nil {
+		return err
+	}
+
+	err = runGit(ctx, target, progress, "fetch", "--progress", "--depth", "1", "origin", ref)
+	if err != nil {
+		return err
+	}
+
+	// Resetting is not cancelled part way so the checkout is never left half updated
+	err = runGit(context.Background(), target, nil, "reset", "--hard", "FETCH_HEAD")
+	if err != nil {
+		return err
+	}
+
+	if !hasSchemes(s.SchemesPath()) {
+		_ = runGit(context.Background(), target, nil, "reset", "--hard", "ORIG_HEAD")
+		return fmt.Errorf("No schemes found in %s", s.Git)
+	}
+
+	return nil
+}
+
+func (s Source) fetchGitArchive(ctx context.Context, progress func(string)) error {
+	url := s.Archive
+	if url == "" {
+		var err error
+		url, err = gitArchiveURL(s.Git, s.Ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	return downloadArchive(ctx, url, s.Path(), s.Subdir, progress)
+}
+
+func (s Source) fetchTarball(ctx context.Context, progress func(string)) error {
+	if strings.HasPrefix(s.Tarball, "http://") || strings.HasPrefix(s.Tarball, "https://") {
+		return downloadArchive(ctx, s.Tarball, s.Path(), s.Subdir, progress)
+	}
+
+	file, err := os.Open(expandHome(s.Tarball))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return replaceDir(s.Path(), s.Subdir, func(tmp string) error {
+		return extractTarball(file, tmp)
+	})
+}
+
+// gitArchiveURL returns the url of a tar.gz archive of ref for repositories
+// hosted on GitHub or GitLab.
+func gitArchiveURL(repo, ref string) (string, error) {
+	// Convert scp style addresses, git@github.com:owner/repo.git
+	if user, rest, found := strings.Cut(repo, "@"); found && !strings.Contains(user, "/") && !strings.Contains(repo, "://") {
+		repo = "ssh://" + strings.Replace(rest, ":", "/", 1)
+	}
+
+	u, err := url.Parse(repo)
+	if err != nil {
+		return "", err
+	}
+
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	switch u.Hostname() {
+	case "github.com":
+		return fmt.Sprintf("https://github.com/%s/archive/%s.tar.gz", repoPath, ref), nil
+	case "gitlab.com":
+		name := path.Base(repoPath)
+		return fmt.Sprintf("https://gitlab.com/%s/-/archive/%s/%s-%s.tar.gz", repoPath, ref, name, strings.ReplaceAll(ref, "/", "-")), nil
+	}
+
+	return "", fmt.Errorf("Can't download an archive of %s, set Archive on the source or FetchWithGit in the config", repo)
+}
+
+// downloadArchive downloads a tarball into target. If the server reports the
+// archive is unchanged since the last download nothing is replaced.
+func downloadArchive(ctx context.Context, url string, target string, subdir string, progress func(string)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	etag, err := os.ReadFile(filepath.Join(target, archiveETagFile))
+	if err == nil && len(etag) > 0 {
+		req.Header.Set("If-None-Match", string(etag))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("Downloading %s: %s", url, resp.Status)
+	}
+
+	counter := &progressReader{reader: resp.Body, progress: progress, prefix: "Downloading "}
+
+	return replaceDir(target, subdir, func(tmp string) error {
+		err := extractTarball(counter, tmp)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			return os.WriteFile(filepath.Join(tmp, archiveETagFile), []byte(etag), 0666)
+		}
+
+		return nil
+	})
+}
+
+func runGit(ctx context.Context, dir string, progress func(string), args ...string) error {
+	output := &progressWriter{progress: progress}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(output.output.String()))
+	}
+
+	return nil
+}
+
+// progressWriter collects command output and passes each line to progress.
+// Git redraws its progress with carriage returns so those also end a line.
+type progressWriter struct {
+	progress func(string)
+	output   bytes.Buffer
+	line     []byte
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.output.Write(p)
+
+	for _, b := range p {
+		if b != '\r' && b != '\n' {
+			w.line = append(w.line, b)
+			continue
+		}
+
+		if line := strings.TrimSpace(string(w.line)); line != "" && w.progress != nil {
+			w.progress(line)
+		}
+
+		w.line = w.line[:0]
+	}
+
+	return len(p), nil
+}
+
+// progressReader reports how much has been read so far.
+type progressReader struct {
+	reader   io.Reader
+	progress func(string)
+	prefix   string
+	read     int64
+	reported int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	if r.progress != nil && (r.read-r.reported >= 256*1024 || err == io.EOF) {
+		r.reported = r.read
+		r.progress(fmt.Sprintf("%s%.1f MB", r.prefix, float64(r.read)/(1024*1024)))
+	}
+
+	return n, err
+}
+
+// replaceDir fills a temporary directory next to target and swaps it in once it
+// contains schemes, so a failed fetch never leaves the source empty.
+func replaceDir(target string, subdir string, fill func(tmp string) error) error {
+	err := os.MkdirAll(filepath.Dir(target), 0777)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(target), "."+filepath.Base(target)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	err = fill(tmp)
+	if err != nil {
+		return err
+	}
+
+	if !hasSchemes(filepath.Join(tmp, subdir)) {
+		return errors.New("No schemes found")
+	}
+
+	old := tmp + ".old"
+
+	err = os.Rename(target, old)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	err = os.Rename(tmp, target)
+	if err != nil {
+		_ = os.Rename(old, target)
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+// extractTarball extracts a tar or tar.gz archive into target. If every entry
+// is inside a single top level directory, as in release archives, it is stripped.
+func extractTarball(r io.Reader, target string) error {
+	buffered := bufio.NewReader(r)
+
+	magic, err := buffered.Peek(2)
+	if err != nil {
+		return err
+	}
+
+	var archive io.Reader = buffered
+
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		archive = gz
+	}
+
+	type entry struct {
+		name string
+		dir  bool
+		data []byte
+	}
+
+	entries := []entry{}
+	prefix := ""
+	stripPrefix := true
+
+	tr := tar.NewReader(archive)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." || name == ".." || path.IsAbs(name) || strings.HasPrefix(name, "../") {
+			continue
+		}
+
+		top, _, _ := strings.Cut(name, "/")
+		if prefix == "" {
+			prefix = top
+		}
+		if top != prefix || (header.Typeflag == tar.TypeReg && !strings.Contains(name, "/")) {
+			stripPrefix = false
+		}
+
+		data := []byte{}
+		if header.Typeflag == tar.TypeReg {
+			data, err = io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+		}
+
+		entries = append(entries, entry{name: name, dir: header.Typeflag == tar.TypeDir, data: data})
+	}
+
+	for _, e := range entries {
+		name := e.name
+		if stripPrefix {
+			name = strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+			if name == "" {
+				continue
+			}
+		}
+
+		dest := filepath.Join(target, filepath.FromSlash(name))
+
+		if e.dir {
+			err := os.MkdirAll(dest, 0777)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := os.MkdirAll(filepath.Dir(dest), 0777)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(dest, e.data, 0666)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type SourceChanges struct {
+	Source  string
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (c SourceChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+func (c SourceChanges) String() string {
+	lines := []string{}
+
+	for _, name := range c.Added {
+		lines = append(lines, "+ "+name)
+	}
+
+	for _, name := range c.Changed {
+		lines = append(lines, "~ "+name)
+	}
+
+	for _, name := range c.Removed {
+		lines = append(lines, "- "+name)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// snapshotSchemes hashes every scheme file under dir, keyed by its path relative to dir.
+func snapshotSchemes(dir string) (map[string][sha256.Size]byte, error) {
+	snapshot := make(map[string][sha256.Size]byte)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		if d.IsDir() || !strings.Contains(d.Name(), ".yaml") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		snapshot[rel] = sha256.Sum256(data)
+
+		return nil
+	})
+
+	return snapshot, err
+}
+
+func hasSchemes(dir string) bool {
+	snapshot, err := snapshotSchemes(dir)
+	return err == nil && len(snapshot) > 0
+}
+
+func diffSchemes(source string, before, after map[string][sha256.Size]byte) SourceChanges {
+	changes := SourceChanges{Source: source}
+
+	name := func(rel string) string {
+		return strings.TrimSuffix(filepath.Base(rel), ".yaml")
+	}
+
+	for rel, hash := range after {
+		prevHash, exists := before[rel]
+
+		switch {
+		case !exists:
+			changes.Added = append(changes.Added, name(rel))
+		case prevHash != hash:
+			changes.Changed = append(changes.Changed, name(rel))
+		}
+	}
+
+	for rel := range before {
+		if _, exists := after[rel]; !exists {
+			changes.Removed = append(changes.Removed, name(rel))
+		}
+	}
+
+	slices.Sort(changes.Added)
+	slices.Sort(changes.Changed)
+	slices.Sort(changes.Removed)
+
+	return changes
+}
+
+// FetchSources fetches every source, carrying on past sources that fail
+// unless the context is cancelled.
+func FetchSources(ctx context.Context, progress func(string)) ([]SourceChanges, error) {
+	changes := []SourceChanges{}
+	errs := []error{}
+
+	for _, source := range config.Sources {
+		if source.Dir != "" {
+			continue
+		}
+
+		sourceProgress := func(line string) {
+			if progress != nil {
+				progress(source.Name + ": " + line)
+			}
+		}
+
+		sourceChanges, err := source.Fetch(ctx, sourceProgress)
+		if errors.Is(err, context.Canceled) {
+			return changes, err
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name, err))
+			continue
+		}
+
+		changes = append(changes, sourceChanges)
+	}
+
+	return changes, errors.Join(errs...)
+}
+
+// FetchSchemes fetches the sources in the background, sending progress lines
+// on progress and closing it once the fetch is done.
+func FetchSchemes(ctx context.Context, progress chan<- string) tea.Cmd {
+	return func() tea.Msg {
+		defer close(progress)
+
+		changes, err := FetchSources(ctx, func(line string) {
+			// Drop progress rather than hold up the fetch if the UI falls behind
+			select {
+			case progress <- line:
+			default:
+			}
+		})
+
+		return fetchResultMsg{
+			themeListItems: GetThemes(),
+			changes:        changes,
+			err:            err,
+		}
+	}
+}
+
+func waitForFetchProgress(progress <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-progress
+		if !ok {
+			return nil
+		}
+
+		return fetchProgressMsg(line)
+	}
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(home, p[1:])
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSourceValidate(t *testing.T) {
+	tests := []struct {
+		source Source
+		valid  bool
+	}{
+		{source: Source{Name: "schemes", Git: "https://github.com/tinted-theming/schemes.git"}, valid: true},
+		{source: Source{Name: "mine", Dir: "~/schemes"}, valid: true},
+		{source: Source{Name: "release", Tarball: "https://example.com/schemes.tar.gz"}, valid: true},
+		{source: Source{Name: "mirror", Git: "https://example.com/schemes.git", Archive: "https://example.com/schemes.tar.gz"}, valid: true},
+		{source: Source{Name: "none"}, valid: false},
+		{source: Source{Name: "both", Git: "https://example.com/schemes.git", Dir: "~/schemes"}, valid: false},
+		{source: Source{Name: "archive", Dir: "~/schemes", Archive: "https://example.com/schemes.tar.gz"}, valid: false},
+		{source: Source{Git: "https://example.com/schemes.git"}, valid: false},
+		{source: Source{Name: customSource, Dir: "~/schemes"}, valid: false},
+		{source: Source{Name: bundledSource, Dir: "~/schemes"}, valid: false},
+		{source: Source{Name: "../schemes", Dir: "~/schemes"}, valid: false},
+	}
+
+	for _, test := range tests {
+		err := test.source.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) = %v, want valid %v", test.source, err, test.valid)
+		}
+	}
+}
+
+func TestValidateSources(t *testing.T) {
+	tests := []struct {
+		names []string
+		valid bool
+	}{
+		{names: []string{"a", "b"}, valid: true},
+		{names: []string{"a", "b", "a"}, valid: false},
+		{names: []string{"schemes", "Schemes"}, valid: false},
+		{names: []string{"a", ""}, valid: false},
+	}
+
+	for _, test := range tests {
+		sources := []Source{}
+		for _, name := range test.names {
+			sources = append(sources, Source{Name: name, Dir: "~/" + name})
+		}
+
+		err := ValidateSources(sources)
+		if (err == nil) != test.valid {
+			t.Errorf("ValidateSources(%v) = %v, want valid %v", test.names, err, test.valid)
+		}
+	}
+}
+
+type tarEntry struct {
+	name string
+	body string
+	dir  bool
+	link bool
+}
+
+func makeTarball(t *testing.T, entries []tarEntry, compress bool) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+
+		switch {
+		case e.dir:
+			header = &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeDir}
+		case e.link:
+			header = &tar.Header{Name: e.name, Linkname: e.body, Typeflag: tar.TypeSymlink}
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !compress {
+		return b.Bytes()
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(b.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return gz.Bytes()
+}
+
+// listFiles returns the files under dir as slash separated relative paths.
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files := []string{}
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(files)
+
+	return files
+}
+
+func TestExtractTarball(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []tarEntry
+		compress bool
+		want     []string
+	}{
+		{
+			name: "strips the top directory",
+			entries: []tarEntry{
+				{name: "schemes-main/", dir: true},
+				{name: "schemes-main/base16/nord.yaml", body: "nord"},
+				{name: "schemes-main/base16/ocean.yaml", body: "ocean"},
+			},
+			compress: true,
+			want:     []string{"base16/nord.yaml", "base16/ocean.yaml"},
+		},
+		{
+			name: "keeps files at the top",
+			entries: []tarEntry{
+				{name: "./nord.yaml", body: "nord"},
+				{name: "./ocean.yaml", body: "ocean"},
+			},
+			want: []string{"nord.yaml", "ocean.yaml"},
+		},
+		{
+			name: "keeps several top directories",
+			entries: []tarEntry{
+				{name: "base16/nord.yaml", body: "nord"},
+				{name: "base24/nord.yaml", body: "nord"},
+			},
+			want: []string{"base16/nord.yaml", "base24/nord.yaml"},
+		},
+		{
+			name: "skips paths outside the target",
+			entries: []tarEntry{
+				{name: "schemes/nord.yaml", body: "nord"},
+				{name: "schemes/../../escaped.yaml", body: "escaped"},
+				{name: "../escaped.yaml", body: "escaped"},
+				{name: "..", dir: true},
+				{name: "/etc/escaped.yaml", body: "escaped"},
+			},
+			want: []string{"nord.yaml"},
+		},
+		{
+			name: "skips links",
+			entries: []tarEntry{
+				{name: "schemes/nord.yaml", body: "nord"},
+				{name: "schemes/passwd.yaml", body: "/etc/passwd", link: true},
+			},
+			want: []string{"nord.yaml"},
+		},
+	}
+
+	for _, test := range tests {
+		root := t.TempDir()
+		target := filepath.Join(root, "target")
+
+		err := extractTarball(bytes.NewReader(makeTarball(t, test.entries, test.compress)), target)
+		if err != nil {
+			t.Errorf("%s: extractTarball returned %v", test.name, err)
+			continue
+		}
+
+		if got := listFiles(t, target); !slices.Equal(got, test.want) {
+			t.Errorf("%s: extracted %v, want %v", test.name, got, test.want)
+		}
+
+		for _, file := range listFiles(t, root) {
+			if !strings.HasPrefix(file, "target/") {
+				t.Errorf("%s: %s was written outside the target", test.name, file)
+			}
+		}
+	}
+}
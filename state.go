@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A ThemeKey identifies a theme by the source it came from and its slug, the
+// path of the scheme file within the source without the extension.
+type ThemeKey struct {
+	Source string `yaml:"source"`
+	Slug   string `yaml:"slug"`
+}
+
+func (k ThemeKey) String() string {
+	if k.Source == "" {
+		return ""
+	}
+
+	return k.Source + "/" + k.Slug
+}
+
+func (k ThemeKey) IsZero() bool {
+	return k == ThemeKey{}
+}
+
+// ParseThemeKey parses a key in the form source/slug.
+func ParseThemeKey(s string) (ThemeKey, bool) {
+	source, slug, found := strings.Cut(s, "/")
+	if !found || source == "" || slug == "" {
+		return ThemeKey{}, false
+	}
+
+	return ThemeKey{Source: source, Slug: slug}, true
+}
+
+// Path returns the scheme file the key refers to.
+func (k ThemeKey) Path() string {
+	filename := filepath.FromSlash(k.Slug) + ".yaml"
+
+	switch k.Source {
+	case customSource:
+		return filepath.Join(config.Paths.CustomSchemes, filename)
+	case bundledSource:
+		return bundledSource + "/" + k.Slug + ".yaml"
+	}
+
+	for _, source := range config.Sources {
+		if source.Name == k.Source {
+			return filepath.Join(source.SchemesPath(), filename)
+		}
+	}
+
+	return ""
+}
+
+// themeKeyForPath works out which source a scheme file belongs to. When the
+// roots of sources are nested the innermost root containing the file wins.
+func themeKeyForPath(path string) (ThemeKey, bool) {
+	if isBundledPath(path) {
+		return ThemeKey{Source: bundledSource, Slug: themeSlug(bundledSource, path)}, true
+	}
+
+	type sourceRoot struct {
+		source string
+		root   string
+	}
+
+	roots := []sourceRoot{{source: customSource, root: config.Paths.CustomSchemes}}
+	for _, source := range config.Sources {
+		roots = append(roots, sourceRoot{source: source.Name, root: source.SchemesPath()})
+	}
+
+	best := sourceRoot{}
+
+	for _, r := range roots {
+		rel, err := filepath.Rel(r.root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		if best.source == "" || len(filepath.Clean(r.root)) > len(filepath.Clean(best.root)) {
+			best = r
+		}
+	}
+
+	if best.source == "" {
+		return ThemeKey{}, false
+	}
+
+	return ThemeKey{Source: best.source, Slug: themeSlug(best.root, path)}, true
+}
+
+func themeSlug(root string, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+
+	return filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+}
+
+type State struct {
+	ActiveTheme ThemeKey `yaml:"activeTheme"`
+}
+
+// ReadState reads the state file, migrating the active theme from the legacy
+// activeTheme file that stored the path of the scheme.
+func ReadState() State {
+	state := State{}
+
+	data, err := os.ReadFile(config.Paths.State)
+	if err == nil {
+		err = yaml.Unmarshal(data, &state)
+		if err != nil {
+			panic(err)
+		}
+
+		return state
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		panic(err)
+	}
+
+	legacyPath, err := os.ReadFile(config.Paths.LegacyActiveTheme)
+	if err != nil {
+		return state
+	}
+
+	// The legacy file is kept until its theme can be found, e.g. once the
+	// source it came from is configured again
+	key, ok := themeKeyForPath(string(legacyPath))
+	if !ok {
+		return state
+	}
+
+	state.ActiveTheme = key
+
+	if WriteState(state) == nil {
+		_ = os.Remove(config.Paths.LegacyActiveTheme)
+	}
+
+	return state
+}
+
+func WriteState(state State) error {
+	d, err := yaml.Marshal(&state)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(config.Paths.State), 0777)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(config.Paths.State, d, 0666)
+}
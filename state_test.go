@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestThemeKeyForPath(t *testing.T) {
+	root := t.TempDir()
+
+	saved := config
+	t.Cleanup(func() { config = saved })
+
+	config.Paths.CustomSchemes = filepath.Join(root, "config", "schemes")
+	config.Paths.BaseSchemes = filepath.Join(root, "data", "schemes")
+	config.Sources = []Source{
+		{Name: "tinted-theming", Git: "https://github.com/tinted-theming/schemes.git", Subdir: "base16"},
+		// Nested inside the directory of the source above
+		{Name: "extra", Dir: filepath.Join(root, "data", "schemes", "tinted-theming", "base16", "extra")},
+		{Name: "sibling", Dir: filepath.Join(root, "data", "schemes", "tinted-theming", "base16-extra")},
+	}
+
+	tests := []struct {
+		path string
+		key  string
+		ok   bool
+	}{
+		{path: filepath.Join(root, "config", "schemes", "mine.yaml"), key: "custom/mine", ok: true},
+		{path: filepath.Join(root, "data", "schemes", "tinted-theming", "base16", "nord.yaml"), key: "tinted-theming/nord", ok: true},
+		{path: filepath.Join(root, "data", "schemes", "tinted-theming", "base16", "extra", "nord.yaml"), key: "extra/nord", ok: true},
+		{path: filepath.Join(root, "data", "schemes", "tinted-theming", "base16", "extra", "dark", "nord.yaml"), key: "extra/dark/nord", ok: true},
+		{path: filepath.Join(root, "data", "schemes", "tinted-theming", "base16-extra", "nord.yaml"), key: "sibling/nord", ok: true},
+		{path: "bundled/base16/nord.yaml", key: "bundled/base16/nord", ok: true},
+		{path: filepath.Join(root, "elsewhere", "nord.yaml"), ok: false},
+	}
+
+	// Repeated as the result must not depend on the order roots are checked in
+	for i := 0; i < 10; i++ {
+		for _, test := range tests {
+			key, ok := themeKeyForPath(test.path)
+			if ok != test.ok || key.String() != test.key {
+				t.Fatalf("themeKeyForPath(%s) = %q, %v, want %q, %v", test.path, key, ok, test.key, test.ok)
+			}
+		}
+	}
+}
@@ -43,12 +43,22 @@ type FilePickerStyles struct {
 	EmptyDirectory   lipgloss.Style
 }
 
+type ColorPickerStyles struct {
+	Label        lipgloss.Style
+	FocusedLabel lipgloss.Style
+	Value        lipgloss.Style
+	Knob         lipgloss.Style
+	Text         lipgloss.Style
+	Cursor       lipgloss.Style
+}
+
 type Styles struct {
-	BaseStyles       ListStyles
-	FocusedStyles    ListStyles
-	HelpStyles       HelpStyles
-	FilePickerStyles FilePickerStyles
-	FormStyles       *huh.Theme
+	BaseStyles        ListStyles
+	FocusedStyles     ListStyles
+	HelpStyles        HelpStyles
+	FilePickerStyles  FilePickerStyles
+	ColorPickerStyles ColorPickerStyles
+	FormStyles        *huh.Theme
 }
 
 type Colors struct {
@@ -123,7 +133,21 @@ func FormStyles(colors Colors) *huh.Theme {
 	return t
 }
 
+// The colour picker is shown inside forms so it is styled to match them.
+func ColorPickerStylesFromForm(t *huh.Theme) ColorPickerStyles {
+	return ColorPickerStyles{
+		Label:        t.Blurred.Title.Copy(),
+		FocusedLabel: t.Focused.Title.Copy(),
+		Value:        t.Focused.Description.Copy(),
+		Knob:         t.Focused.TextInput.Text.Copy(),
+		Text:         t.Focused.TextInput.Text.Copy(),
+		Cursor:       t.Focused.TextInput.Cursor.Copy(),
+	}
+}
+
 func DefaultStyles(colors Colors) Styles {
+	formStyles := FormStyles(colors)
+
 	return Styles{
 		BaseStyles: ListStyles{
 			Title:                 lipgloss.NewStyle().Foreground(colors.Base00).Background(colors.Base03),
@@ -177,7 +201,8 @@ func DefaultStyles(colors Colors) Styles {
 			FileSize:         lipgloss.NewStyle().Foreground(colors.Base00),
 			EmptyDirectory:   lipgloss.NewStyle().Foreground(colors.Base02),
 		},
-		FormStyles: FormStyles(colors),
+		ColorPickerStyles: ColorPickerStylesFromForm(formStyles),
+		FormStyles:        formStyles,
 	}
 }
 
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClaraSmyth/pin/builder"
+)
+
+// terminalWriteTimeout stops a terminal that isn't reading its output, e.g.
+// after ctrl+s, from blocking the apply.
+const terminalWriteTimeout = 200 * time.Millisecond
+
+// The base16 colour of each of the 22 ANSI colours, following base16-shell.
+// Colours 16 to 21 hold the base16 colours that don't fit the 16 ANSI colours.
+var terminalPaletteKeys = []string{
+	"base00", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base05",
+	"base03", "base08", "base0B", "base0A", "base0D", "base0E", "base0C", "base07",
+	"base09", "base0F", "base01", "base02", "base04", "base06",
+}
+
+// TerminalSequences returns the OSC escape sequences that set the terminal
+// palette (OSC 4), foreground (OSC 10), background (OSC 11) and cursor (OSC 12).
+func TerminalSequences(scheme builder.Scheme) (string, error) {
+	colors := map[string]string{}
+
+	for _, key := range builder.Base16Keys {
+		c, err := builder.ParseHexColor(scheme.Palette[key])
+		if err != nil {
+			return "", err
+		}
+
+		colors[key] = fmt.Sprintf("rgb:%02x/%02x/%02x", c.R, c.G, c.B)
+	}
+
+	var b strings.Builder
+
+	for i, key := range terminalPaletteKeys {
+		fmt.Fprintf(&b, "\033]4;%d;%s\033\\", i, colors[key])
+	}
+
+	fmt.Fprintf(&b, "\033]10;%s\033\\", colors["base05"])
+	fmt.Fprintf(&b, "\033]11;%s\033\\", colors["base00"])
+	fmt.Fprintf(&b, "\033]12;%s\033\\", colors["base05"])
+
+	return b.String(), nil
+}
+
+// RecolorTerminals writes the palette of the scheme to the current terminal
+// and every other terminal of the user, so open shells change colour without
+// restarting. It returns the terminals that were written to.
+func RecolorTerminals(scheme builder.Scheme) ([]string, error) {
+	sequences, err := TerminalSequences(scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	written := []string{}
+
+	for _, path := range append([]string{"/dev/tty"}, userTerminals()...) {
+		file, err := openTerminal(path)
+		if err != nil {
+			continue
+		}
+
+		// Deadlines are only supported where the terminal is opened non blocking
+		_ = file.SetWriteDeadline(time.Now().Add(terminalWriteTimeout))
+
+		_, err = file.WriteString(sequences)
+		file.Close()
+		if err != nil {
+			continue
+		}
+
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+// recolorTerminalsIfEnabled recolours the terminals when TerminalColors is set
+// in the config. Terminals are recoloured on a best effort basis so errors are
+// ignored.
+func recolorTerminalsIfEnabled(scheme builder.Scheme) {
+	if !config.TerminalColors {
+		return
+	}
+
+	_, _ = RecolorTerminals(scheme)
+}
@@ -0,0 +1,77 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// userTerminals returns the pseudo-terminals owned by the current user, except
+// the controlling terminal as that is written through /dev/tty.
+func userTerminals() []string {
+	paths, err := filepath.Glob("/dev/pts/[0-9]*")
+	if err != nil {
+		return nil
+	}
+
+	controlling, hasControlling := controllingTerminal()
+	terminals := []string{}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			continue
+		}
+
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok || int(stat.Uid) != os.Getuid() {
+			continue
+		}
+
+		if hasControlling && uint64(stat.Rdev) == controlling {
+			continue
+		}
+
+		terminals = append(terminals, path)
+	}
+
+	return terminals
+}
+
+// controllingTerminal returns the device number of the controlling terminal
+// from the tty_nr field of /proc/self/stat, which is encoded like st_rdev.
+func controllingTerminal() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, false
+	}
+
+	// The command name is in parentheses and may contain spaces and parentheses
+	end := strings.LastIndex(string(data), ")")
+	if end == -1 {
+		return 0, false
+	}
+
+	fields := strings.Fields(string(data)[end+1:])
+	if len(fields) < 5 {
+		return 0, false
+	}
+
+	tty, err := strconv.ParseUint(fields[4], 10, 64)
+	if err != nil || tty == 0 {
+		return 0, false
+	}
+
+	return tty, true
+}
+
+// openTerminal opens the terminal without making it the controlling terminal,
+// and without blocking if the terminal isn't reading its output.
+func openTerminal(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "os"
+
+// userTerminals returns nil as other terminals of the user can only be found
+// on linux, only the current terminal is recoloured.
+func userTerminals() []string {
+	return nil
+}
+
+func openTerminal(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY, 0)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// Editors often write a file several times when saving, so changes are
+// collected until nothing has changed for watchDebounce.
+const watchDebounce = 200 * time.Millisecond
+
+type watchChanges struct {
+	apps    map[string]bool
+	appData bool
+	schemes map[string]bool
+}
+
+func (c *watchChanges) empty() bool {
+	return len(c.apps) == 0 && !c.appData && len(c.schemes) == 0
+}
+
+// RunWatch re-applies the active theme when templates, custom schemes or
+// apps.yaml change until stop is closed. Template changes only re-render the
+// app they belong to, changes to the active scheme re-apply the whole theme.
+func RunWatch(stop <-chan struct{}, log func(string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// The config directory is watched rather than apps.yaml as editors
+	// often replace files instead of writing to them
+	dirs := []string{config.Paths.Home, config.Paths.Templates, config.Paths.CustomSchemes}
+
+	entries, _ := os.ReadDir(config.Paths.Templates)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(config.Paths.Templates, entry.Name()))
+		}
+	}
+
+	for _, dir := range dirs {
+		err = watcher.Add(dir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	apps := readWatchedApps()
+	changes := watchChanges{apps: map[string]bool{}, schemes: map[string]bool{}}
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	log("Watching for changes")
+
+	for {
+		select {
+		case <-stop:
+			return nil
+
+		case err := <-watcher.Errors:
+			return err
+
+		case event := <-watcher.Events:
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+
+			path := filepath.Clean(event.Name)
+			dir := filepath.Dir(path)
+
+			switch {
+			case path == config.Paths.Apps:
+				changes.appData = true
+
+			case path == config.Paths.Templates || path == config.Paths.CustomSchemes:
+				// Created after pin watch started
+				if event.Has(fsnotify.Create) {
+					_ = watcher.Add(path)
+				}
+				continue
+
+			case dir == config.Paths.Templates:
+				if info, err := os.Stat(path); err == nil && info.IsDir() {
+					_ = watcher.Add(path)
+				}
+				changes.apps[filepath.Base(path)] = true
+
+			case filepath.Dir(dir) == config.Paths.Templates:
+				changes.apps[filepath.Base(dir)] = true
+
+			case dir == config.Paths.CustomSchemes:
+				changes.schemes[path] = true
+
+			default:
+				continue
+			}
+
+			timer.Reset(watchDebounce)
+
+		case <-timer.C:
+			if changes.appData {
+				current := readWatchedApps()
+
+				for name, app := range current {
+					if previous, ok := apps[name]; !ok || previous != app {
+						changes.apps[name] = true
+					}
+				}
+
+				apps = current
+				changes.appData = false
+			}
+
+			if !changes.empty() {
+				message, err := applyWatchChanges(changes, apps)
+				if err != nil {
+					log(err.Error())
+				} else if message != "" {
+					log(message)
+				}
+			}
+
+			// Applying writes apps.yaml, which shouldn't trigger another apply
+			apps = readWatchedApps()
+			changes = watchChanges{apps: map[string]bool{}, schemes: map[string]bool{}}
+		}
+	}
+}
+
+func applyWatchChanges(changes watchChanges, apps map[string]App) (string, error) {
+	theme, found := FindTheme(ReadState().ActiveTheme.String())
+	if !found {
+		return "", errors.New("No theme has been applied yet")
+	}
+
+	if changes.schemes[filepath.Clean(theme.Path)] {
+		result, err := applyTheme(theme)
+		if err != nil {
+			return "", errors.New("There was an error applying this theme!")
+		}
+
+		return applyLogMessage("Re-applied "+theme.DisplayName(), result), nil
+	}
+
+	names := []string{}
+	for name := range changes.apps {
+		if apps[name].Active {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	result, err := applyThemeToApps(theme, names)
+	if err != nil {
+		return "", errors.New("There was an error applying this theme!")
+	}
+
+	return "Re-rendered " + result.String(), nil
+}
+
+func readWatchedApps() map[string]App {
+	apps := map[string]App{}
+
+	data, err := os.ReadFile(config.Paths.Apps)
+	if err != nil {
+		return apps
+	}
+
+	_ = yaml.Unmarshal(data, &apps)
+
+	return apps
+}